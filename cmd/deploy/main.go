@@ -0,0 +1,230 @@
+// Command deploy syncs a directory in bucket layout to an S3-compatible bucket
+// such as Cloudflare R2. Only objects whose content or metadata changed are uploaded.
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/uji/ujiprog.com/site"
+)
+
+// deployConfig holds the sync source, target and behavior
+type deployConfig struct {
+	Dir      string
+	Endpoint string
+	Region   string
+	Bucket   string
+	// Prune deletes bucket objects that do not exist locally
+	Prune  bool
+	DryRun bool
+	// Keep lists key prefixes that are never pruned (objects written by the worker,
+	// fonts that are not in the repository)
+	Keep []string
+	// Exclude lists key patterns (path.Match) that are neither uploaded nor pruned
+	Exclude []string
+}
+
+func main() {
+	var cfg deployConfig
+	flag.StringVar(&cfg.Dir, "dir", ".generated", "Directory to sync (bucket layout)")
+	flag.StringVar(&cfg.Endpoint, "endpoint", defaultEndpoint(), "S3 API endpoint URL (defaults to R2 for $CLOUDFLARE_ACCOUNT_ID)")
+	flag.StringVar(&cfg.Region, "region", "auto", "Bucket region")
+	flag.StringVar(&cfg.Bucket, "bucket", "ujiprog-static", "Bucket name")
+	flag.BoolVar(&cfg.Prune, "prune", false, "Delete bucket objects that do not exist locally")
+	flag.BoolVar(&cfg.DryRun, "dry-run", false, "Print the changes without applying them")
+	keep := flag.String("keep", "og-cache/,fonts/", "Comma-separated key prefixes never pruned")
+	exclude := flag.String("exclude", "articles/build-manifest.json", "Comma-separated key patterns to ignore")
+	flag.Parse()
+	cfg.Keep = splitList(*keep)
+	cfg.Exclude = splitList(*exclude)
+
+	if err := deploy(context.Background(), cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// defaultEndpoint returns the R2 S3 endpoint for the Cloudflare account in the environment
+func defaultEndpoint() string {
+	if account := os.Getenv("CLOUDFLARE_ACCOUNT_ID"); account != "" {
+		return "https://" + account + ".r2.cloudflarestorage.com"
+	}
+	return ""
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// localObject is a file to be synced to the bucket
+type localObject struct {
+	path string
+	etag string // hex MD5, as reported by the bucket for single-part uploads
+	size int64
+}
+
+// deploy uploads changed objects and optionally prunes stale ones.
+// Credentials are read from $AWS_ACCESS_KEY_ID and $AWS_SECRET_ACCESS_KEY.
+func deploy(ctx context.Context, cfg deployConfig) error {
+	if cfg.Endpoint == "" {
+		return fmt.Errorf("no endpoint: set -endpoint or $CLOUDFLARE_ACCOUNT_ID")
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint: %w", err)
+	}
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:  credentials.NewEnvAWS(),
+		Secure: endpoint.Scheme != "http",
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return err
+	}
+
+	local, err := cfg.localObjects()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", cfg.Dir, err)
+	}
+
+	remote := make(map[string]string)
+	for obj := range client.ListObjects(ctx, cfg.Bucket, minio.ListObjectsOptions{Recursive: true}) {
+		if obj.Err != nil {
+			return fmt.Errorf("failed to list bucket: %w", obj.Err)
+		}
+		remote[obj.Key] = strings.Trim(obj.ETag, `"`)
+	}
+
+	var uploaded, unchanged, deleted int
+	for _, key := range slices.Sorted(maps.Keys(local)) {
+		obj := local[key]
+		contentType, cacheControl := site.ObjectMetadata(key)
+		if remote[key] == obj.etag {
+			// The listing has no metadata, so check it for objects whose content is unchanged
+			info, err := client.StatObject(ctx, cfg.Bucket, key, minio.StatObjectOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to stat %s: %w", key, err)
+			}
+			if info.ContentType == contentType && info.Metadata.Get("Cache-Control") == cacheControl {
+				unchanged++
+				continue
+			}
+			log.Printf("Updating metadata of %s (%s, %s)", key, contentType, cacheControl)
+		} else {
+			log.Printf("Uploading %s (%s)", key, contentType)
+		}
+		uploaded++
+		if cfg.DryRun {
+			continue
+		}
+		if err := putFile(ctx, client, cfg.Bucket, key, obj, contentType, cacheControl); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", key, err)
+		}
+	}
+
+	if cfg.Prune {
+		for _, key := range slices.Sorted(maps.Keys(remote)) {
+			if _, ok := local[key]; ok || cfg.kept(key) || cfg.excluded(key) {
+				continue
+			}
+			log.Printf("Deleting %s", key)
+			deleted++
+			if cfg.DryRun {
+				continue
+			}
+			if err := client.RemoveObject(ctx, cfg.Bucket, key, minio.RemoveObjectOptions{}); err != nil {
+				return fmt.Errorf("failed to delete %s: %w", key, err)
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("%d uploaded, %d unchanged, %d deleted", uploaded, unchanged, deleted)
+	if cfg.DryRun {
+		summary += " (dry run)"
+	}
+	log.Printf("Deploy complete: %s", summary)
+	return nil
+}
+
+// localObjects walks the directory and returns its files keyed by bucket key
+func (cfg deployConfig) localObjects() (map[string]localObject, error) {
+	objects := make(map[string]localObject)
+	err := filepath.WalkDir(cfg.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(cfg.Dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if cfg.excluded(key) {
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		sum := md5.Sum(data)
+		objects[key] = localObject{path: p, etag: hex.EncodeToString(sum[:]), size: int64(len(data))}
+		return nil
+	})
+	return objects, err
+}
+
+// kept reports whether key is under a prefix that is never pruned
+func (cfg deployConfig) kept(key string) bool {
+	for _, prefix := range cfg.Keep {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// excluded reports whether key matches an exclude pattern
+func (cfg deployConfig) excluded(key string) bool {
+	for _, pattern := range cfg.Exclude {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// putFile uploads a local file with its metadata
+func putFile(ctx context.Context, client *minio.Client, bucket, key string, obj localObject, contentType, cacheControl string) error {
+	f, err := os.Open(obj.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = client.PutObject(ctx, bucket, key, f, obj.size, minio.PutObjectOptions{
+		ContentType:  contentType,
+		CacheControl: cacheControl,
+	})
+	return err
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeObject is an object held by fakeS3
+type fakeObject struct {
+	data         []byte
+	contentType  string
+	cacheControl string
+}
+
+// fakeS3 is a single-bucket stand-in for the S3 API calls deploy makes
+// (ListObjectsV2, HEAD, PUT and DELETE)
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]fakeObject
+	puts    []string
+	deletes []string
+}
+
+// etag returns the quoted hex MD5 that S3 reports for single-part uploads
+func etag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		type content struct {
+			Key  string
+			ETag string
+			Size int
+		}
+		var result struct {
+			XMLName  xml.Name `xml:"ListBucketResult"`
+			Contents []content
+		}
+		for k, obj := range f.objects {
+			result.Contents = append(result.Contents, content{k, etag(obj.data), len(obj.data)})
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		xml.NewEncoder(w).Encode(result)
+	case r.Method == http.MethodHead:
+		obj, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", etag(obj.data))
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Header().Set("Cache-Control", obj.cacheControl)
+		w.Header().Set("Last-Modified", "Thu, 01 Jan 2026 00:00:00 GMT")
+		w.Header().Set("Content-Length", "0")
+	case r.Method == http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = fakeObject{data, r.Header.Get("Content-Type"), r.Header.Get("Cache-Control")}
+		f.puts = append(f.puts, key)
+		w.Header().Set("ETag", etag(data))
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		f.deletes = append(f.deletes, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "unsupported", http.StatusNotImplemented)
+	}
+}
+
+// writeFiles creates files (slash-separated names) under dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDeploy(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	fake := &fakeS3{objects: map[string]fakeObject{
+		// Same content and metadata: skipped
+		"public/style.css": {[]byte("body{}"), "text/css; charset=utf-8", "public, max-age=604800"},
+		// Same content, outdated Cache-Control: re-uploaded
+		"articles/hello.html": {[]byte("<p>hello</p>"), "text/html; charset=utf-8", "public, max-age=31536000, immutable"},
+		// Not in the directory: pruned unless kept or excluded
+		"articles/old.html":            {[]byte("old"), "text/html; charset=utf-8", ""},
+		"og-cache/hello-1.png":         {[]byte("png"), "image/png", ""},
+		"articles/build-manifest.json": {[]byte("{}"), "application/json", ""},
+	}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"public/style.css":             "body{}",
+		"articles/hello.html":          "<p>hello</p>",
+		"articles/new.html":            "<p>new</p>",
+		"articles/build-manifest.json": "{}",
+	})
+
+	cfg := deployConfig{
+		Dir:      dir,
+		Endpoint: srv.URL,
+		Region:   "us-east-1",
+		Bucket:   "bucket",
+		Prune:    true,
+		Keep:     []string{"og-cache/"},
+		Exclude:  []string{"articles/build-manifest.json"},
+	}
+
+	dryRun := cfg
+	dryRun.DryRun = true
+	if err := deploy(context.Background(), dryRun); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.puts) != 0 || len(fake.deletes) != 0 {
+		t.Fatalf("dry run changed the bucket: puts %v, deletes %v", fake.puts, fake.deletes)
+	}
+
+	if err := deploy(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(fake.puts)
+	if want := []string{"articles/hello.html", "articles/new.html"}; !slices.Equal(fake.puts, want) {
+		t.Errorf("uploaded %v, want %v", fake.puts, want)
+	}
+	if want := []string{"articles/old.html"}; !slices.Equal(fake.deletes, want) {
+		t.Errorf("deleted %v, want %v", fake.deletes, want)
+	}
+	if got := fake.objects["articles/hello.html"].cacheControl; got != "public, max-age=0, must-revalidate" {
+		t.Errorf("Cache-Control not updated: %q", got)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// staticObject maps a source file in the repository to its bucket key
+type staticObject struct {
+	Key  string
+	Path string
+}
+
+// staticObjects returns the source files uploaded to the bucket as-is.
+// Files in public/ keep their public/ prefix and are served at the top level.
+func staticObjects() ([]staticObject, error) {
+	objects := []staticObject{
+		{Key: "index.html", Path: "index.html"},
+		{Key: "fonts/DMSans-Bold.ttf", Path: "fonts/DMSans/DMSans-Bold.ttf"},
+		{Key: "fonts/NotoSansJP-Bold.ttf", Path: "fonts/NotoSansJP/NotoSansJP-Bold.ttf"},
+		{Key: "fonts/NotoEmoji-Bold.ttf", Path: "fonts/NotoEmoji/NotoEmoji-Bold.ttf"},
+	}
+
+	// OG card templates and their layouts
+	templateFiles, err := filepath.Glob("templates/*.png")
+	if err != nil {
+		return nil, err
+	}
+	layoutFiles, err := filepath.Glob("templates/*.json")
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range append(templateFiles, layoutFiles...) {
+		objects = append(objects, staticObject{Key: "templates/" + filepath.Base(path), Path: path})
+	}
+
+	publicFiles, err := filepath.Glob("public/*")
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range publicFiles {
+		objects = append(objects, staticObject{Key: "public/" + filepath.Base(path), Path: path})
+	}
+
+	return objects, nil
+}
+
+// runStage copies the static objects into a directory in bucket layout,
+// next to the generated articles, for cmd/deploy to sync
+func runStage(args []string) error {
+	fs := flag.NewFlagSet("stage", flag.ExitOnError)
+	dir := fs.String("dir", ".generated", "Directory to copy static objects into (bucket layout)")
+	fs.Parse(args)
+
+	return copyStaticObjects(*dir)
+}
+
+// copyStaticObjects copies static objects into dir using the bucket layout.
+// Missing source files are skipped with a warning. Files left in the static
+// directories (public/, templates/, fonts/) by earlier runs whose source is gone
+// are removed, so that deploy prunes them from the bucket.
+func copyStaticObjects(dir string) error {
+	objects, err := staticObjects()
+	if err != nil {
+		return err
+	}
+
+	staged := make(map[string]bool)
+	for _, obj := range objects {
+		data, err := os.ReadFile(obj.Path)
+		if os.IsNotExist(err) {
+			log.Printf("Warning: %s not found, skipping", obj.Path)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		dst := filepath.Join(dir, filepath.FromSlash(obj.Key))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dst, data, 0644); err != nil {
+			return err
+		}
+		staged[obj.Key] = true
+	}
+
+	return removeUnstaged(dir, staged)
+}
+
+// staticDirs are the key directories that only hold static objects
+var staticDirs = []string{"fonts", "public", "templates"}
+
+// removeUnstaged deletes the files in staticDirs that were not staged in this run
+func removeUnstaged(dir string, staged map[string]bool) error {
+	for _, staticDir := range staticDirs {
+		err := filepath.WalkDir(filepath.Join(dir, staticDir), func(p string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return err
+			}
+			if staged[filepath.ToSlash(rel)] {
+				return nil
+			}
+			if err := os.Remove(p); err != nil {
+				return err
+			}
+			log.Printf("Removed: %s", p)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
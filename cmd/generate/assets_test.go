@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopyStaticObjectsRemovesDeletedFiles(t *testing.T) {
+	repo := t.TempDir()
+	for _, dir := range []string{"public", "templates"} {
+		if err := os.Mkdir(filepath.Join(repo, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, repo, "index.html", "index")
+	writeFile(t, repo, "public/style.css", "css")
+	writeFile(t, repo, "public/old.js", "js")
+	writeFile(t, repo, "templates/card.png", "png")
+	t.Chdir(repo)
+
+	out := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(out, "articles"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, out, "articles/hello.html", "article")
+	writeFile(t, out, "og-meta.json", "{}")
+	if err := copyStaticObjects(out); err != nil {
+		t.Fatal(err)
+	}
+
+	// Delete sources and stage again
+	for _, name := range []string{"public/old.js", "templates/card.png"} {
+		if err := os.Remove(filepath.Join(repo, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := copyStaticObjects(out); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]bool{
+		"index.html":          true,
+		"public/style.css":    true,
+		"public/old.js":       false,
+		"templates/card.png":  false,
+		"articles/hello.html": true, // generated files are left alone
+		"og-meta.json":        true,
+	} {
+		if _, err := os.Stat(filepath.Join(out, name)); (err == nil) != want {
+			t.Errorf("%s exists: %v, want %v", name, err == nil, want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/uji/ujiprog.com/markdown"
+)
+
+// runCodeCSS writes the stylesheet for the syntax highlighting classes in article code blocks
+func runCodeCSS(args []string) error {
+	fs := flag.NewFlagSet("code-css", flag.ExitOnError)
+	output := fs.String("output", "public/code.css", "Path to write the stylesheet to")
+	fs.Parse(args)
+
+	var buf bytes.Buffer
+	if err := markdown.WriteHighlightCSS(&buf); err != nil {
+		return err
+	}
+	if err := os.WriteFile(*output, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	log.Printf("Generated: %s", *output)
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/uji/ujiprog.com/platform"
+)
+
+// fetchTimeout bounds fetching all platforms
+const fetchTimeout = time.Minute
+
+// runFetch fetches the article lists of the external platforms and merges them into articles.json.
+// Platforms that fail to fetch keep their previous entries.
+func runFetch(args []string) error {
+	accounts := platform.DefaultAccounts()
+	fs := flag.NewFlagSet("fetch", flag.ExitOnError)
+	articlesJSONPath := fs.String("articles-json", "public/articles.json", "Path to articles.json to update")
+	fs.StringVar(&accounts.Zenn, "zenn", accounts.Zenn, "Zenn username (empty to skip)")
+	fs.StringVar(&accounts.Note, "note", accounts.Note, "note creator ID (empty to skip)")
+	fs.StringVar(&accounts.SpeakerDeck, "speakerdeck", accounts.SpeakerDeck, "SpeakerDeck username (empty to skip)")
+	fs.StringVar(&accounts.Qiita, "qiita", accounts.Qiita, "Qiita user ID (empty to skip)")
+	fs.StringVar(&accounts.Hatena, "hatena", accounts.Hatena, "Hatena Blog host, e.g. example.hatenablog.com (empty to skip)")
+	fs.StringVar(&accounts.DevTo, "devto", accounts.DevTo, "dev.to username (empty to skip)")
+	fs.StringVar(&accounts.Connpass, "connpass", accounts.Connpass, "connpass nickname to list talks for; requires $CONNPASS_API_KEY (empty to skip)")
+	fs.Parse(args)
+	accounts.ConnpassAPIKey = os.Getenv("CONNPASS_API_KEY")
+
+	data, err := readArticlesJSON(*articlesJSONPath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	data, err = platform.Refresh(ctx, data, accounts.Sources(&http.Client{}))
+	if err != nil {
+		return err
+	}
+	if err := writeArticlesJSON(*articlesJSONPath, data); err != nil {
+		return err
+	}
+	log.Printf("Updated: %s", *articlesJSONPath)
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Paths served by the live reloader
+const (
+	liveReloadEventsPath = "/__livereload"
+	liveReloadScriptPath = "/__livereload.js"
+)
+
+// liveReloadScript reloads the page when the server reports a rebuild.
+// It is served as a file because the CSP forbids inline scripts.
+const liveReloadScript = `new EventSource("` + liveReloadEventsPath + `").addEventListener("reload", function () {
+  location.reload();
+});
+`
+
+// liveReloader notifies connected browsers over Server-Sent Events
+type liveReloader struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+}
+
+// newLiveReloader creates a liveReloader with no clients
+func newLiveReloader() *liveReloader {
+	return &liveReloader{
+		clients: make(map[chan struct{}]struct{}),
+	}
+}
+
+// Reload tells every connected browser to reload
+func (l *liveReloader) Reload() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for ch := range l.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Wrap serves the live reload endpoints and injects the reload script into HTML responses from next
+func (l *liveReloader) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case liveReloadEventsPath:
+			l.serveEvents(w, req)
+			return
+		case liveReloadScriptPath:
+			w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+			w.Header().Set("Cache-Control", "no-store")
+			w.Write([]byte(liveReloadScript))
+			return
+		}
+
+		// Always send full responses so the injected script is never cached away
+		req.Header.Del("If-None-Match")
+		req.Header.Del("If-Modified-Since")
+
+		buf := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(buf, req)
+
+		body := buf.body.Bytes()
+		if strings.HasPrefix(buf.header.Get("Content-Type"), "text/html") {
+			body = injectLiveReload(body)
+			buf.header.Set("Content-Length", strconv.Itoa(len(body)))
+			buf.header.Del("ETag")
+		}
+		for k, v := range buf.header {
+			w.Header()[k] = v
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(buf.status)
+		w.Write(body)
+	})
+}
+
+// serveEvents streams reload events until the client disconnects
+func (l *liveReloader) serveEvents(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := make(chan struct{}, 1)
+	l.mu.Lock()
+	l.clients[ch] = struct{}{}
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		delete(l.clients, ch)
+		l.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-ch:
+			fmt.Fprint(w, "event: reload\ndata: {}\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// injectLiveReload inserts the reload script tag before </body>
+func injectLiveReload(body []byte) []byte {
+	tag := []byte(`<script src="` + liveReloadScriptPath + `"></script>`)
+	idx := bytes.LastIndex(body, []byte("</body>"))
+	if idx == -1 {
+		return append(body, tag...)
+	}
+	result := make([]byte, 0, len(body)+len(tag))
+	result = append(result, body[:idx]...)
+	result = append(result, tag...)
+	return append(result, body[idx:]...)
+}
+
+// bufferedResponse captures a response so it can be rewritten before sending
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
@@ -2,118 +2,282 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
-	"sort"
+	"runtime"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/uji/ujiprog.com/markdown"
+	"github.com/uji/ujiprog.com/ogimage"
+	"github.com/uji/ujiprog.com/platform"
 )
 
-type ArticlesData struct {
-	Articles []Article `json:"articles"`
-}
-
-type Article struct {
-	Title       string `json:"title"`
-	URL         string `json:"url"`
-	PublishedAt string `json:"published_at"`
-	Platform    string `json:"platform"`
-}
-
 // OGMeta represents OG image metadata for an article
-type OGMeta struct {
-	Title string `json:"title"` // OG画像用タイトル（改行含む）
-}
+type OGMeta = ogimage.Meta
 
 // OGMetaData maps article slug to OG metadata
 type OGMetaData map[string]OGMeta
 
+// generateConfig holds the inputs and outputs of an article build
+type generateConfig struct {
+	ArticlesDir      string
+	OutputDir        string
+	TemplatePath     string
+	ArticlesJSONPath string
+	OGMetaPath       string
+	// OGImages enables rendering <slug>.png next to the HTML output
+	OGImages bool
+	OGImage  ogImageConfig
+	// EmbedLockPath is the lockfile recording expanded URL embeds ("" disables it)
+	EmbedLockPath string
+	RefreshEmbeds bool
+	Offline       bool
+	// Jobs is the number of articles processed in parallel
+	Jobs int
+	// Force regenerates articles even if the build manifest shows them up to date
+	Force bool
+}
+
+// defaultEmbedLockPath is the committed lockfile of expanded URL embeds
+const defaultEmbedLockPath = "embeds.lock.json"
+
 func main() {
-	articlesDir := flag.String("articles", "articles", "Directory containing markdown articles")
-	outputDir := flag.String("output", "build/articles", "Directory to output generated HTML and images")
-	templatePath := flag.String("template", "templates/article.html", "Path to article HTML template")
-	articlesJSONPath := flag.String("articles-json", "public/articles.json", "Path to articles.json for merging")
-	ogMetaPath := flag.String("og-meta", "", "Path to output og-meta.json (optional)")
+	if len(os.Args) > 1 {
+		var run func([]string) error
+		switch os.Args[1] {
+		case "serve":
+			run = runServe
+		case "stage":
+			run = runStage
+		case "fetch":
+			run = runFetch
+		case "code-css":
+			run = runCodeCSS
+		}
+		if run != nil {
+			if err := run(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
+	var cfg generateConfig
+	flag.StringVar(&cfg.ArticlesDir, "articles", "articles", "Directory containing markdown articles")
+	flag.StringVar(&cfg.OutputDir, "output", "build/articles", "Directory to output generated HTML and images")
+	flag.StringVar(&cfg.TemplatePath, "template", "templates/article.html", "Path to article HTML template")
+	flag.StringVar(&cfg.ArticlesJSONPath, "articles-json", "public/articles.json", "Path to articles.json for merging")
+	flag.StringVar(&cfg.OGMetaPath, "og-meta", "", "Path to output og-meta.json (optional)")
+	flag.BoolVar(&cfg.OGImages, "og-images", false, "Pre-render OG images next to the HTML output")
+	flag.StringVar(&cfg.OGImage.TemplatesDir, "og-templates", "templates", "Directory containing OG card templates (<name>.png) and layouts (<name>.json)")
+	flag.StringVar(&cfg.EmbedLockPath, "embed-lock", defaultEmbedLockPath, "Path to the embed lockfile (empty to always fetch)")
+	flag.BoolVar(&cfg.RefreshEmbeds, "refresh-embeds", false, "Fetch every embed again and update the lockfile")
+	flag.BoolVar(&cfg.Offline, "offline", false, "Use only locked embeds and never fetch")
+	flag.BoolVar(&cfg.Force, "force", false, "Regenerate all articles even if their inputs are unchanged")
+	flag.IntVar(&cfg.Jobs, "jobs", runtime.NumCPU(), "Number of articles to process in parallel")
+	ogFonts := flag.String("og-fonts", strings.Join(defaultOGFontPaths, ","), "Comma-separated OG image font fallback chain")
 	flag.Parse()
+	cfg.OGImage.FontPaths = strings.Split(*ogFonts, ",")
+
+	if err := generate(cfg); err != nil {
+		log.Fatal(err)
+	}
+}
 
+// generate renders all markdown articles and updates articles.json and og-meta.json
+func generate(cfg generateConfig) error {
 	// Ensure output directory exists
-	if err := os.MkdirAll(*outputDir, 0755); err != nil {
-		log.Fatalf("Failed to create output directory: %v", err)
+	if err := os.MkdirAll(cfg.OutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
 	// Find all markdown files
-	mdFiles, err := filepath.Glob(filepath.Join(*articlesDir, "*.md"))
+	mdFiles, err := filepath.Glob(filepath.Join(cfg.ArticlesDir, "*.md"))
 	if err != nil {
-		log.Fatalf("Failed to find markdown files: %v", err)
+		return fmt.Errorf("failed to find markdown files: %w", err)
 	}
 
 	if len(mdFiles) == 0 {
 		log.Println("No markdown files found")
-		return
+		return nil
 	}
 
 	// Create parser and renderer
-	parser := markdown.NewParser()
-	renderer, err := markdown.NewRenderer(*templatePath)
+	var parserOpts []markdown.Option
+	var embedLock *markdown.EmbedLock
+	if cfg.EmbedLockPath != "" {
+		mode, err := cfg.lockMode()
+		if err != nil {
+			return err
+		}
+		embedLock, err = markdown.LoadEmbedLock(cfg.EmbedLockPath)
+		if err != nil {
+			return fmt.Errorf("failed to load embed lock: %w", err)
+		}
+		parserOpts = append(parserOpts, markdown.WithEmbedLock(embedLock, mode))
+	}
+	parser := markdown.NewParser(parserOpts...)
+	renderer, err := markdown.NewRenderer(cfg.TemplatePath)
 	if err != nil {
-		log.Fatalf("Failed to create renderer: %v", err)
+		return fmt.Errorf("failed to create renderer: %w", err)
 	}
 
-	// Process each markdown file
-	var localArticles []Article
-	ogMetaData := make(OGMetaData)
-	for _, mdFile := range mdFiles {
-		log.Printf("Processing: %s", mdFile)
-
-		// Parse markdown with URL expansion
-		article, err := parser.ParseFileWithExpansion(mdFile)
+	var ogRenderer *ogImageRenderer
+	if cfg.OGImages {
+		ogRenderer, err = newOGImageRenderer(cfg.OGImage)
 		if err != nil {
-			log.Printf("Failed to parse %s: %v", mdFile, err)
-			continue
+			return fmt.Errorf("failed to create OG image renderer: %w", err)
 		}
+	}
 
-		// Render HTML
-		if err := renderer.RenderToFile(article, *outputDir); err != nil {
-			log.Printf("Failed to render %s: %v", mdFile, err)
-			continue
+	manifestPath := filepath.Join(cfg.OutputDir, manifestFile)
+	previous, err := loadManifest(manifestPath)
+	if err != nil {
+		return fmt.Errorf("failed to load build manifest: %w", err)
+	}
+	generator, err := generatorVersion()
+	if err != nil {
+		log.Printf("Warning: Failed to determine the generator version: %v", err)
+	}
+	reusable := previous.reusable(generator)
+	templateHash, err := hashFile(cfg.TemplatePath)
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+	builder := &articleBuilder{
+		parser:       parser,
+		renderer:     renderer,
+		outputDir:    cfg.OutputDir,
+		templateHash: templateHash,
+		lock:         embedLock,
+		previous:     reusable,
+		// Refreshing embeds may change any article
+		force: cfg.Force || cfg.RefreshEmbeds,
+	}
+
+	// Parse and render the articles in parallel. Results are kept in file order
+	// so that the outputs below do not depend on scheduling.
+	results := make([]*articleResult, len(mdFiles))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range max(cfg.Jobs, 1) {
+		wg.Go(func() {
+			for i := range jobs {
+				results[i] = builder.process(mdFiles[i])
+			}
+		})
+	}
+	for i := range mdFiles {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Articles that failed (e.g. unsupported TeX commands) fail the build before
+	// any shared output is written, so articles.json, og-meta.json, the manifest
+	// and the embed lock keep describing the last complete build
+	failed := 0
+	for _, result := range results {
+		if result == nil {
+			failed++
 		}
-		log.Printf("Generated: %s/%s.html", *outputDir, article.Filename)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to generate %d of %d articles", failed, len(mdFiles))
+	}
+
+	var localArticles []platform.Article
+	ogMetaData := make(OGMetaData)
+	manifest := &buildManifest{Generator: generator, Articles: make(map[string]manifestEntry)}
+	for i, result := range results {
+		mdFile := mdFiles[i]
+		article := result.article
 
 		// Collect OG metadata
-		ogMetaData[article.Filename] = OGMeta{
-			Title: article.Meta.OGTitle(),
+		ogMeta := OGMeta{
+			Title:       article.Meta.OGTitle(),
+			PublishedAt: article.Meta.PublishedAt.Format(time.RFC3339),
+			Tags:        article.Meta.Tags,
+			Template:    article.Meta.OGTemplate,
+		}
+		ogMetaData[article.Filename] = ogMeta
+
+		// Pre-render OG image
+		if ogRenderer != nil {
+			if err := builder.renderOGImage(ogRenderer, ogMeta, article.Filename, &result.entry); err != nil {
+				log.Printf("Failed to render OG image for %s: %v", mdFile, err)
+			}
 		}
 
 		// Add to local articles list
-		localArticles = append(localArticles, Article{
+		localArticle := platform.Article{
 			Title:       article.Meta.Title,
 			URL:         "/articles/" + article.Filename,
 			PublishedAt: article.Meta.PublishedAt.Format(time.RFC3339),
 			Platform:    "blog",
-		})
+		}
+		if !article.Meta.UpdatedAt.IsZero() {
+			localArticle.UpdatedAt = article.Meta.UpdatedAt.Format(time.RFC3339)
+		}
+		localArticles = append(localArticles, localArticle)
+		manifest.Articles[article.Filename] = result.entry
+	}
+
+	if embedLock != nil {
+		used := make(map[string]bool)
+		for _, entry := range manifest.Articles {
+			for url := range entry.Embeds {
+				used[url] = true
+			}
+		}
+		embedLock.Retain(used)
+		if err := embedLock.Save(cfg.EmbedLockPath); err != nil {
+			log.Printf("Warning: Failed to save embed lock: %v", err)
+		}
+	}
+
+	removeStaleOutputs(previous, mdFiles, cfg.OutputDir)
+	if err := manifest.save(manifestPath); err != nil {
+		log.Printf("Warning: Failed to save build manifest: %v", err)
 	}
 
 	// Merge with existing articles.json
-	if err := mergeArticlesJSON(*articlesJSONPath, localArticles); err != nil {
+	if err := mergeArticlesJSON(cfg.ArticlesJSONPath, []string{"blog"}, localArticles); err != nil {
 		log.Printf("Warning: Failed to merge articles.json: %v", err)
 	} else {
-		log.Printf("Updated: %s", *articlesJSONPath)
+		log.Printf("Updated: %s", cfg.ArticlesJSONPath)
 	}
 
 	// Save OG metadata if path is specified
-	if *ogMetaPath != "" {
-		if err := saveOGMeta(*ogMetaPath, ogMetaData); err != nil {
+	if cfg.OGMetaPath != "" {
+		if err := saveOGMeta(cfg.OGMetaPath, ogMetaData); err != nil {
 			log.Printf("Warning: Failed to save og-meta.json: %v", err)
 		} else {
-			log.Printf("Generated: %s", *ogMetaPath)
+			log.Printf("Generated: %s", cfg.OGMetaPath)
 		}
 	}
 
 	log.Println("Generation complete!")
+	return nil
+}
+
+// lockMode returns how the embed lock is used for the given flags
+func (cfg generateConfig) lockMode() (markdown.LockMode, error) {
+	switch {
+	case cfg.RefreshEmbeds && cfg.Offline:
+		return 0, fmt.Errorf("-refresh-embeds and -offline cannot be used together")
+	case cfg.RefreshEmbeds:
+		return markdown.LockRefresh, nil
+	case cfg.Offline:
+		return markdown.LockOffline, nil
+	}
+	return markdown.LockReuse, nil
 }
 
 // saveOGMeta saves OG metadata to a JSON file
@@ -130,51 +294,40 @@ func saveOGMeta(path string, data OGMetaData) error {
 	return nil
 }
 
-// mergeArticlesJSON merges local articles with existing articles.json
-func mergeArticlesJSON(path string, localArticles []Article) error {
-	var existingData ArticlesData
-
-	// Read existing articles.json if it exists
-	if data, err := os.ReadFile(path); err == nil {
-		if err := json.Unmarshal(data, &existingData); err != nil {
-			return fmt.Errorf("failed to parse existing articles.json: %w", err)
-		}
+// mergeArticlesJSON replaces the articles of the given platforms in articles.json
+// with articles, keeping the articles of the other platforms
+func mergeArticlesJSON(path string, platforms []string, articles []platform.Article) error {
+	data, err := readArticlesJSON(path)
+	if err != nil {
+		return err
 	}
+	return writeArticlesJSON(path, platform.Merge(data, platforms, articles))
+}
 
-	// Create a map of existing articles (excluding blog platform to allow updates)
-	articleMap := make(map[string]Article)
-	for _, a := range existingData.Articles {
-		if a.Platform != "blog" {
-			articleMap[a.URL] = a
-		}
+// readArticlesJSON reads articles.json. A missing file yields no articles.
+func readArticlesJSON(path string) (platform.ArticlesData, error) {
+	var data platform.ArticlesData
+	jsonBytes, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return data, nil
 	}
-
-	// Add local articles (blog platform)
-	for _, a := range localArticles {
-		articleMap[a.URL] = a
+	if err != nil {
+		return data, err
 	}
-
-	// Convert map back to slice
-	var allArticles []Article
-	for _, a := range articleMap {
-		allArticles = append(allArticles, a)
+	if err := json.Unmarshal(jsonBytes, &data); err != nil {
+		return data, fmt.Errorf("failed to parse existing articles.json: %w", err)
 	}
+	return data, nil
+}
 
-	// Sort by published_at descending
-	sort.Slice(allArticles, func(i, j int) bool {
-		return allArticles[i].PublishedAt > allArticles[j].PublishedAt
-	})
-
-	// Write updated articles.json
-	newData := ArticlesData{Articles: allArticles}
-	jsonBytes, err := json.MarshalIndent(newData, "", "  ")
+// writeArticlesJSON writes articles.json
+func writeArticlesJSON(path string, data platform.ArticlesData) error {
+	jsonBytes, err := data.Encode()
 	if err != nil {
 		return fmt.Errorf("failed to marshal articles: %w", err)
 	}
-
 	if err := os.WriteFile(path, jsonBytes, 0644); err != nil {
 		return fmt.Errorf("failed to write articles.json: %w", err)
 	}
-
 	return nil
 }
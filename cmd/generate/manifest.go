@@ -0,0 +1,306 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/uji/ujiprog.com/markdown"
+)
+
+// manifestFile is the name of the build manifest written to the output directory
+const manifestFile = "build-manifest.json"
+
+// buildManifest records the inputs and outputs of each generated article so
+// that unchanged articles can be skipped. It only drives generation: cmd/deploy
+// compares the output directory with the bucket's ETags instead, which also
+// covers the static objects and does not depend on local state from the last
+// deploy, so the manifest itself is not uploaded.
+type buildManifest struct {
+	// Generator identifies the generator build (see generatorVersion). Entries
+	// written by another build are all stale, since the markdown renderer or
+	// the ogimage code may have changed.
+	Generator string                   `json:"generator"`
+	Articles  map[string]manifestEntry `json:"articles"` // keyed by article slug
+}
+
+// manifestEntry holds the hashes of one article's inputs and outputs
+type manifestEntry struct {
+	Source   string `json:"source"`
+	Template string `json:"template"`
+	// Embeds maps each standalone URL to the hash of its embed ("" if it was left as a link)
+	Embeds map[string]string `json:"embeds,omitempty"`
+	// OGImage is the hash of the OG image inputs when images are pre-rendered
+	OGImage string `json:"og_image,omitempty"`
+	// Outputs maps file names in the output directory to their hashes
+	Outputs map[string]string `json:"outputs"`
+}
+
+// loadManifest reads a build manifest. A missing file yields an empty manifest.
+func loadManifest(path string) (*buildManifest, error) {
+	manifest := &buildManifest{Articles: make(map[string]manifestEntry)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if manifest.Articles == nil {
+		manifest.Articles = make(map[string]manifestEntry)
+	}
+	return manifest, nil
+}
+
+// generatorVersion returns a hash of the running executable, which changes
+// whenever the code compiled into cmd/generate or its dependencies changes
+func generatorVersion() (string, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return hashFile(path)
+}
+
+// reusable returns the manifest whose entries the generator build identified by
+// generator may reuse. Outputs of a different (or unknown) generator are never
+// reused, since it may render every article and OG image differently.
+func (m *buildManifest) reusable(generator string) *buildManifest {
+	if generator != "" && m.Generator == generator {
+		return m
+	}
+	if len(m.Articles) > 0 {
+		log.Println("Generator changed, regenerating all articles")
+	}
+	return &buildManifest{Articles: make(map[string]manifestEntry)}
+}
+
+// save writes the manifest to path
+func (m *buildManifest) save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal build manifest: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// hashBytes returns the hex SHA-256 of data
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashFile returns the hex SHA-256 of the file at path
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return hashBytes(data), nil
+}
+
+// hashEmbed returns the hash of an embed, or "" for a URL left as a link
+func hashEmbed(embed *markdown.Embed) string {
+	if embed == nil {
+		return ""
+	}
+	data, _ := json.Marshal(embed)
+	return hashBytes(data)
+}
+
+// embedHashes returns the embed hashes recorded for an article
+func embedHashes(embeds map[string]*markdown.Embed) map[string]string {
+	if len(embeds) == 0 {
+		return nil
+	}
+	hashes := make(map[string]string, len(embeds))
+	for url, embed := range embeds {
+		hashes[url] = hashEmbed(embed)
+	}
+	return hashes
+}
+
+// embedsUpToDate reports whether the locked embeds still match the recorded ones.
+// Without a lock, embeds are fetched on every build and cannot be compared.
+func embedsUpToDate(recorded map[string]string, lock *markdown.EmbedLock) bool {
+	if len(recorded) == 0 {
+		return true
+	}
+	if lock == nil {
+		return false
+	}
+	for url, hash := range recorded {
+		var current *markdown.Embed
+		if embed, ok := lock.Lookup(url); ok {
+			current = &embed
+		}
+		if hashEmbed(current) != hash {
+			return false
+		}
+	}
+	return true
+}
+
+// outputsUpToDate reports whether the recorded outputs exist unmodified in dir
+func outputsUpToDate(outputs map[string]string, dir string) bool {
+	if len(outputs) == 0 {
+		return false
+	}
+	for name, hash := range outputs {
+		if current, err := hashFile(filepath.Join(dir, name)); err != nil || current != hash {
+			return false
+		}
+	}
+	return true
+}
+
+// articleBuilder generates articles, skipping those whose inputs are unchanged since the previous build
+type articleBuilder struct {
+	parser       *markdown.Parser
+	renderer     *markdown.Renderer
+	outputDir    string
+	templateHash string
+	lock         *markdown.EmbedLock
+	previous     *buildManifest
+	force        bool
+}
+
+// articleResult is a processed article and its manifest entry
+type articleResult struct {
+	article *markdown.ParsedArticle
+	entry   manifestEntry
+}
+
+// process parses and renders one markdown file.
+// It returns nil if the article could not be generated.
+func (b *articleBuilder) process(mdFile string) *articleResult {
+	log.Printf("Processing: %s", mdFile)
+
+	source, err := os.ReadFile(mdFile)
+	if err != nil {
+		log.Printf("Failed to read %s: %v", mdFile, err)
+		return nil
+	}
+	slug := strings.TrimSuffix(filepath.Base(mdFile), filepath.Ext(mdFile))
+	htmlFile := slug + ".html"
+	entry := manifestEntry{
+		Source:   hashBytes(source),
+		Template: b.templateHash,
+	}
+
+	// Only read the frontmatter of articles whose inputs and output are unchanged
+	if prev, ok := b.previous.Articles[slug]; ok && !b.force &&
+		prev.Source == entry.Source && prev.Template == entry.Template &&
+		embedsUpToDate(prev.Embeds, b.lock) &&
+		outputsUpToDate(map[string]string{htmlFile: prev.Outputs[htmlFile]}, b.outputDir) {
+		article, err := b.parser.Parse(source, slug)
+		if err == nil {
+			err = checkMeta(article)
+		}
+		if err != nil {
+			log.Printf("Failed to parse %s: %v", mdFile, err)
+			return nil
+		}
+		log.Printf("Up to date: %s/%s", b.outputDir, htmlFile)
+		entry.Embeds = prev.Embeds
+		entry.Outputs = map[string]string{htmlFile: prev.Outputs[htmlFile]}
+		return &articleResult{article: article, entry: entry}
+	}
+
+	// Parse markdown with URL expansion
+	article, err := b.parser.ParseWithExpansion(source, slug)
+	if err == nil {
+		err = checkMeta(article)
+	}
+	if err != nil {
+		log.Printf("Failed to parse %s: %v", mdFile, err)
+		return nil
+	}
+
+	// Render HTML
+	html, err := b.renderer.Render(article)
+	if err != nil {
+		log.Printf("Failed to render %s: %v", mdFile, err)
+		return nil
+	}
+	if err := os.WriteFile(filepath.Join(b.outputDir, htmlFile), []byte(html), 0644); err != nil {
+		log.Printf("Failed to write %s: %v", htmlFile, err)
+		return nil
+	}
+	log.Printf("Generated: %s/%s", b.outputDir, htmlFile)
+
+	entry.Embeds = embedHashes(article.Embeds)
+	entry.Outputs = map[string]string{htmlFile: hashBytes([]byte(html))}
+	return &articleResult{article: article, entry: entry}
+}
+
+// checkMeta rejects frontmatter that would break the outputs built from it,
+// such as an og_template that is not a plain template name
+func checkMeta(article *markdown.ParsedArticle) error {
+	_, err := OGMeta{Template: article.Meta.OGTemplate}.TemplateName()
+	return err
+}
+
+// renderOGImage pre-renders the OG image of an article unless its inputs are unchanged,
+// recording the image in entry
+func (b *articleBuilder) renderOGImage(r *ogImageRenderer, meta OGMeta, slug string, entry *manifestEntry) error {
+	inputHash, err := r.inputHash(meta)
+	if err != nil {
+		return err
+	}
+	pngFile := slug + ".png"
+
+	prev := b.previous.Articles[slug]
+	if !b.force && prev.OGImage == inputHash &&
+		outputsUpToDate(map[string]string{pngFile: prev.Outputs[pngFile]}, b.outputDir) {
+		log.Printf("Up to date: %s/%s", b.outputDir, pngFile)
+		entry.OGImage = inputHash
+		entry.Outputs[pngFile] = prev.Outputs[pngFile]
+		return nil
+	}
+
+	if err := r.renderToFile(meta, slug, b.outputDir); err != nil {
+		return err
+	}
+	outputHash, err := hashFile(filepath.Join(b.outputDir, pngFile))
+	if err != nil {
+		return err
+	}
+	log.Printf("Generated: %s/%s", b.outputDir, pngFile)
+	entry.OGImage = inputHash
+	entry.Outputs[pngFile] = outputHash
+	return nil
+}
+
+// removeStaleOutputs deletes the outputs of articles recorded in the previous build
+// whose source no longer exists, so that deploy prunes them from the bucket
+func removeStaleOutputs(previous *buildManifest, mdFiles []string, outputDir string) {
+	current := make(map[string]bool, len(mdFiles))
+	for _, mdFile := range mdFiles {
+		current[strings.TrimSuffix(filepath.Base(mdFile), filepath.Ext(mdFile))] = true
+	}
+
+	for slug, entry := range previous.Articles {
+		if current[slug] {
+			continue
+		}
+		for name := range entry.Outputs {
+			path := filepath.Join(outputDir, name)
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Printf("Warning: Failed to remove %s: %v", path, err)
+				continue
+			}
+			log.Printf("Removed: %s", path)
+		}
+	}
+}
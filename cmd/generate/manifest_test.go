@@ -0,0 +1,209 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/uji/ujiprog.com/markdown"
+)
+
+// writeFile writes data to dir/name and returns the path
+func writeFile(t *testing.T, dir, name, data string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// loadTestLock returns an embed lock holding embeds
+func loadTestLock(t *testing.T, embeds string) *markdown.EmbedLock {
+	t.Helper()
+	lock, err := markdown.LoadEmbedLock(writeFile(t, t.TempDir(), "embeds.lock.json", `{"embeds": `+embeds+`}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lock
+}
+
+func TestEmbedsUpToDate(t *testing.T) {
+	lock := loadTestLock(t, `{"https://example.com/a": {"html": "<p>a</p>"}}`)
+	locked := hashEmbed(&markdown.Embed{HTML: "<p>a</p>"})
+
+	tests := []struct {
+		name     string
+		recorded map[string]string
+		lock     *markdown.EmbedLock
+		want     bool
+	}{
+		{"no embeds", nil, nil, true},
+		{"without a lock", map[string]string{"https://example.com/a": locked}, nil, false},
+		{"unchanged", map[string]string{"https://example.com/a": locked}, lock, true},
+		{"changed", map[string]string{"https://example.com/a": "old"}, lock, false},
+		{"still a link", map[string]string{"https://example.com/b": ""}, lock, true},
+		{"link now locked", map[string]string{"https://example.com/a": ""}, lock, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := embedsUpToDate(tt.recorded, tt.lock); got != tt.want {
+				t.Errorf("embedsUpToDate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputsUpToDate(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.html", "a")
+	hash := hashBytes([]byte("a"))
+
+	tests := []struct {
+		name    string
+		outputs map[string]string
+		want    bool
+	}{
+		{"unchanged", map[string]string{"a.html": hash}, true},
+		{"no outputs", nil, false},
+		{"modified", map[string]string{"a.html": hashBytes([]byte("b"))}, false},
+		{"missing", map[string]string{"a.html": hash, "a.png": hash}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := outputsUpToDate(tt.outputs, dir); got != tt.want {
+				t.Errorf("outputsUpToDate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveStaleOutputs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"kept.html", "removed.html", "removed.png"} {
+		writeFile(t, dir, name, name)
+	}
+	previous := &buildManifest{Articles: map[string]manifestEntry{
+		"kept":    {Outputs: map[string]string{"kept.html": ""}},
+		"removed": {Outputs: map[string]string{"removed.html": "", "removed.png": ""}},
+	}}
+
+	removeStaleOutputs(previous, []string{"articles/kept.md"}, dir)
+
+	for name, want := range map[string]bool{"kept.html": true, "removed.html": false, "removed.png": false} {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != want {
+			t.Errorf("%s exists: %v, want %v", name, err == nil, want)
+		}
+	}
+}
+
+func TestManifestReusable(t *testing.T) {
+	previous := &buildManifest{
+		Generator: "v1",
+		Articles:  map[string]manifestEntry{"hello": {Source: "x"}},
+	}
+	if got := previous.reusable("v1"); got != previous {
+		t.Error("entries of the same generator were not reused")
+	}
+	for _, generator := range []string{"v2", ""} {
+		if got := previous.reusable(generator); len(got.Articles) != 0 {
+			t.Errorf("generator %q reused %d entries", generator, len(got.Articles))
+		}
+	}
+}
+
+// newTestBuilder returns an articleBuilder writing to a temp dir, and an article to build
+func newTestBuilder(t *testing.T) (*articleBuilder, string) {
+	t.Helper()
+	dir := t.TempDir()
+	templatePath := writeFile(t, dir, "article.html", "<main>{{.Content}}</main>")
+	renderer, err := markdown.NewRenderer(templatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputDir := filepath.Join(dir, "out")
+	if err := os.Mkdir(outputDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	mdFile := writeFile(t, dir, "hello.md", "---\ntitle: Hello\npublished_at: 2026-01-22\n---\n\nhello\n")
+	return &articleBuilder{
+		parser:       markdown.NewParser(),
+		renderer:     renderer,
+		outputDir:    outputDir,
+		templateHash: "template",
+		previous:     &buildManifest{Articles: make(map[string]manifestEntry)},
+	}, mdFile
+}
+
+func TestArticleBuilderProcess(t *testing.T) {
+	b, mdFile := newTestBuilder(t)
+	htmlPath := filepath.Join(b.outputDir, "hello.html")
+
+	first := b.process(mdFile)
+	if first == nil {
+		t.Fatal("process failed")
+	}
+	if !outputsUpToDate(first.entry.Outputs, b.outputDir) {
+		t.Fatalf("outputs not recorded: %v", first.entry.Outputs)
+	}
+
+	// rebuilt reports whether processing again with the first build as the
+	// previous one rewrites the output
+	rebuilt := func(t *testing.T) bool {
+		t.Helper()
+		old := time.Unix(0, 0)
+		if err := os.Chtimes(htmlPath, old, old); err != nil {
+			t.Fatal(err)
+		}
+		b.previous = &buildManifest{Articles: map[string]manifestEntry{"hello": first.entry}}
+		result := b.process(mdFile)
+		if result == nil {
+			t.Fatal("process failed")
+		}
+		if result.article.Meta.Title != "Hello" {
+			t.Errorf("title %q", result.article.Meta.Title)
+		}
+		info, err := os.Stat(htmlPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return !info.ModTime().Equal(old)
+	}
+
+	if rebuilt(t) {
+		t.Error("unchanged article was regenerated")
+	}
+
+	b.force = true
+	if !rebuilt(t) {
+		t.Error("-force did not regenerate the article")
+	}
+	b.force = false
+
+	b.templateHash = "changed"
+	if !rebuilt(t) {
+		t.Error("template change did not regenerate the article")
+	}
+	b.templateHash = "template"
+
+	if err := os.WriteFile(htmlPath, []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !rebuilt(t) {
+		t.Error("modified output was not regenerated")
+	}
+
+	writeFile(t, filepath.Dir(mdFile), "hello.md", "---\ntitle: Hello\npublished_at: 2026-01-22\n---\n\nchanged\n")
+	if !rebuilt(t) {
+		t.Error("source change did not regenerate the article")
+	}
+}
+
+func TestArticleBuilderRejectsTemplatePath(t *testing.T) {
+	b, mdFile := newTestBuilder(t)
+	writeFile(t, filepath.Dir(mdFile), "hello.md", "---\ntitle: Hello\nog_template: ../x\n---\n\nhello\n")
+	if result := b.process(mdFile); result != nil {
+		t.Error("an og_template outside templates/ was accepted")
+	}
+}
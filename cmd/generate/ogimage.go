@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/uji/ujiprog.com/ogimage"
+)
+
+// ogImageConfig holds the inputs for rendering OG images at build time
+type ogImageConfig struct {
+	// TemplatesDir contains <name>.png card templates and their <name>.json layouts
+	TemplatesDir string
+	// FontPaths is the font fallback chain; missing files are skipped
+	FontPaths []string
+}
+
+// defaultOGFontPaths mirrors the font chain the worker loads from the bucket
+var defaultOGFontPaths = []string{
+	"fonts/DMSans/DMSans-Bold.ttf",
+	"fonts/NotoSansJP/NotoSansJP-Bold.ttf",
+	"fonts/NotoEmoji/NotoEmoji-Bold.ttf",
+}
+
+// ogImageRenderer renders OG images at build time, creating one generator per card template
+type ogImageRenderer struct {
+	cfg        ogImageConfig
+	fonts      [][]byte
+	fontsHash  string
+	generators map[string]*ogimage.Generator
+	templates  map[string]string // template name -> hash of its image and layout
+}
+
+// newOGImageRenderer loads the font chain
+func newOGImageRenderer(cfg ogImageConfig) (*ogImageRenderer, error) {
+	var fonts [][]byte
+	fontsHash := sha256.New()
+	for _, path := range cfg.FontPaths {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			log.Printf("Warning: OG font %s not found, skipping", path)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read OG font: %w", err)
+		}
+		fonts = append(fonts, data)
+		fontsHash.Write(data)
+	}
+
+	return &ogImageRenderer{
+		cfg:        cfg,
+		fonts:      fonts,
+		fontsHash:  hex.EncodeToString(fontsHash.Sum(nil)),
+		generators: make(map[string]*ogimage.Generator),
+		templates:  make(map[string]string),
+	}, nil
+}
+
+// generator returns the generator for the card template of meta, loading it on first use.
+// A missing <name>.json falls back to ogimage.DefaultLayout.
+func (r *ogImageRenderer) generator(meta OGMeta) (*ogimage.Generator, error) {
+	name, err := meta.TemplateName()
+	if err != nil {
+		return nil, err
+	}
+	if gen, ok := r.generators[name]; ok {
+		return gen, nil
+	}
+
+	templateData, err := os.ReadFile(filepath.Join(r.cfg.TemplatesDir, name+".png"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OG template: %w", err)
+	}
+
+	var layout *ogimage.Layout
+	layoutData, err := os.ReadFile(filepath.Join(r.cfg.TemplatesDir, name+".json"))
+	if err == nil {
+		if layout, err = ogimage.ParseLayout(layoutData); err != nil {
+			return nil, fmt.Errorf("%s.json: %w", name, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read OG layout: %w", err)
+	}
+
+	gen, err := ogimage.NewGenerator(templateData, r.fonts, layout)
+	if err != nil {
+		return nil, err
+	}
+	r.generators[name] = gen
+	return gen, nil
+}
+
+// inputHash returns a hash of everything the OG image for meta is rendered from
+func (r *ogImageRenderer) inputHash(meta OGMeta) (string, error) {
+	name, err := meta.TemplateName()
+	if err != nil {
+		return "", err
+	}
+	templateHash, ok := r.templates[name]
+	if !ok {
+		h := sha256.New()
+		for _, ext := range []string{".png", ".json"} {
+			data, err := os.ReadFile(filepath.Join(r.cfg.TemplatesDir, name+ext))
+			if err != nil && !(ext == ".json" && os.IsNotExist(err)) {
+				return "", fmt.Errorf("failed to read OG template: %w", err)
+			}
+			h.Write(data)
+		}
+		templateHash = hex.EncodeToString(h.Sum(nil))
+		r.templates[name] = templateHash
+	}
+
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return "", err
+	}
+	return hashBytes([]byte(r.fontsHash + "\n" + templateHash + "\n" + string(metaJSON))), nil
+}
+
+// renderToFile renders the OG image for meta to <outputDir>/<slug>.png
+func (r *ogImageRenderer) renderToFile(meta OGMeta, slug, outputDir string) error {
+	gen, err := r.generator(meta)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := gen.Generate(meta.Card(), &buf); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outputDir, slug+".png"), buf.Bytes(), 0644)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/uji/ujiprog.com/site"
+	"github.com/uji/ujiprog.com/storage"
+)
+
+// runServe builds the site into a local directory, serves it with the same
+// handlers as the worker and rebuilds whenever sources change
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8787", "Address to listen on")
+	dir := fs.String("dir", ".generated", "Directory to build the site into (bucket layout)")
+	articlesDir := fs.String("articles", "articles", "Directory containing markdown articles")
+	templatePath := fs.String("template", "templates/article.html", "Path to article HTML template")
+	articlesJSONPath := fs.String("articles-json", "public/articles.json", "Path to articles.json for merging")
+	ogImages := fs.Bool("og-images", false, "Pre-render OG images to preview them as static files")
+	offline := fs.Bool("offline", false, "Use only locked embeds and never fetch")
+	fs.Parse(args)
+
+	cfg := generateConfig{
+		ArticlesDir:      *articlesDir,
+		OutputDir:        filepath.Join(*dir, "articles"),
+		TemplatePath:     *templatePath,
+		ArticlesJSONPath: *articlesJSONPath,
+		OGMetaPath:       filepath.Join(*dir, "og-meta.json"),
+		OGImages:         *ogImages,
+		OGImage: ogImageConfig{
+			TemplatesDir: filepath.Dir(*templatePath),
+			FontPaths:    defaultOGFontPaths,
+		},
+		EmbedLockPath: defaultEmbedLockPath,
+		Offline:       *offline,
+		Jobs:          runtime.NumCPU(),
+	}
+
+	build := func() {
+		if err := generate(cfg); err != nil {
+			log.Printf("Build failed: %v", err)
+			return
+		}
+		if err := copyStaticObjects(*dir); err != nil {
+			log.Printf("Failed to copy static files: %v", err)
+		}
+	}
+	build()
+
+	reloader := newLiveReloader()
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	for _, d := range []string{*articlesDir, filepath.Dir(*templatePath), "public"} {
+		if err := watcher.Add(d); err != nil {
+			return err
+		}
+	}
+	go watchAndRebuild(watcher, cfg.ArticlesJSONPath, func() {
+		build()
+		reloader.Reload()
+	})
+
+	handler := site.New(storage.NewDir(*dir))
+	log.Printf("Serving %s on http://%s", *dir, *addr)
+	return http.ListenAndServe(*addr, reloader.Wrap(handler))
+}
+
+// rebuildDelay debounces bursts of file events (e.g. editor save sequences)
+const rebuildDelay = 200 * time.Millisecond
+
+// watchAndRebuild calls rebuild after file changes settle.
+// Writes to ignorePath are skipped because the build itself updates it.
+// Rebuilds run one at a time on a single goroutine, and changes made during a
+// rebuild are coalesced into one more rebuild after it.
+func watchAndRebuild(watcher *fsnotify.Watcher, ignorePath string, rebuild func()) {
+	pending := make(chan struct{}, 1)
+	go func() {
+		for range pending {
+			rebuild()
+		}
+	}()
+	request := func() {
+		select {
+		case pending <- struct{}{}:
+		default:
+			// A rebuild is already queued
+		}
+	}
+
+	var timer *time.Timer
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) == filepath.Clean(ignorePath) {
+				continue
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) &&
+				!event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+				continue
+			}
+			log.Printf("Changed: %s", event.Name)
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(rebuildDelay, request)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("Watch error: %v", err)
+		}
+	}
+}
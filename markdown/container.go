@@ -0,0 +1,213 @@
+package markdown
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// kindContainer is the NodeKind of containerNode
+var kindContainer = ast.NewNodeKind("Container")
+
+// containerNode is a Zenn-style ::: container block (message or details)
+// holding its inner markdown as children
+type containerNode struct {
+	ast.BaseBlock
+	// Name is "message" or "details"
+	Name string
+	// Alert marks a ":::message alert" block
+	Alert bool
+	// Title is the summary of a details block
+	Title []byte
+	// fence is the number of colons opening the block; it is closed by at least as many
+	fence int
+	// codeFenceChar and codeFenceLen describe a fenced code block open inside the
+	// container (0 if none). Lines of ::: in it are code and do not close the container.
+	codeFenceChar byte
+	codeFenceLen  int
+}
+
+// Kind implements ast.Node
+func (n *containerNode) Kind() ast.NodeKind {
+	return kindContainer
+}
+
+// Dump implements ast.Node
+func (n *containerNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Name": n.Name, "Title": string(n.Title)}, nil)
+}
+
+// containerParser parses ::: container blocks:
+//
+//	:::message
+//	:::message alert
+//	:::details タイトル
+//
+// A block ends with a line of at least as many colons as it started with,
+// so containers nest by giving the outer one more colons (::::details).
+type containerParser struct{}
+
+// Trigger implements parser.BlockParser
+func (b *containerParser) Trigger() []byte {
+	return []byte{':'}
+}
+
+// Open implements parser.BlockParser
+func (b *containerParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 {
+		return nil, parser.NoChildren
+	}
+	fence := colonCount(line[pos:])
+	if fence < 3 {
+		return nil, parser.NoChildren
+	}
+
+	name, rest, _ := bytes.Cut(bytes.TrimSpace(line[pos+fence:]), []byte(" "))
+	node := &containerNode{Name: string(name), fence: fence}
+	rest = bytes.TrimSpace(rest)
+	switch node.Name {
+	case "message":
+		switch string(rest) {
+		case "":
+		case "alert":
+			node.Alert = true
+		default:
+			return nil, parser.NoChildren
+		}
+	case "details":
+		node.Title = rest
+	default:
+		return nil, parser.NoChildren
+	}
+
+	// The opening line holds no content
+	reader.Advance(segment.Len() - trailingNewline(line) + segment.Padding)
+	return node, parser.HasChildren
+}
+
+// Continue implements parser.BlockParser
+func (b *containerParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	n := node.(*containerNode)
+	line, segment := reader.PeekLine()
+	if w, pos := util.IndentWidth(line, reader.LineOffset()); w < 4 {
+		rest := line[pos:]
+		if n.codeFenceLen > 0 {
+			if closesCodeFence(rest, n.codeFenceChar, n.codeFenceLen) {
+				n.codeFenceChar, n.codeFenceLen = 0, 0
+			}
+			return parser.Continue | parser.HasChildren
+		}
+		if char, length := codeFenceOpening(rest); length > 0 {
+			n.codeFenceChar, n.codeFenceLen = char, length
+			return parser.Continue | parser.HasChildren
+		}
+		fence := colonCount(rest)
+		if fence >= 3 && fence >= n.fence && util.IsBlank(rest[fence:]) {
+			reader.Advance(segment.Len() - trailingNewline(line) + segment.Padding)
+			return parser.Close
+		}
+	}
+	return parser.Continue | parser.HasChildren
+}
+
+// Close implements parser.BlockParser
+func (b *containerParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+// CanInterruptParagraph implements parser.BlockParser
+func (b *containerParser) CanInterruptParagraph() bool {
+	return true
+}
+
+// CanAcceptIndentedLine implements parser.BlockParser
+func (b *containerParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// colonCount returns the number of leading colons in line
+func colonCount(line []byte) int {
+	i := 0
+	for i < len(line) && line[i] == ':' {
+		i++
+	}
+	return i
+}
+
+// codeFenceOpening returns the character and length of the ``` or ~~~ fence
+// opening a code block on line, or a length of 0 if line does not open one
+func codeFenceOpening(line []byte) (byte, int) {
+	if len(line) == 0 || (line[0] != '`' && line[0] != '~') {
+		return 0, 0
+	}
+	n := 0
+	for n < len(line) && line[n] == line[0] {
+		n++
+	}
+	// The info string of a backtick fence cannot contain backticks
+	if n < 3 || (line[0] == '`' && bytes.IndexByte(line[n:], '`') >= 0) {
+		return 0, 0
+	}
+	return line[0], n
+}
+
+// closesCodeFence reports whether line closes a code block opened by length chars
+func closesCodeFence(line []byte, char byte, length int) bool {
+	n := 0
+	for n < len(line) && line[n] == char {
+		n++
+	}
+	return n >= length && util.IsBlank(line[n:])
+}
+
+// trailingNewline returns 1 if line ends with a newline, otherwise 0
+func trailingNewline(line []byte) int {
+	if len(line) > 0 && line[len(line)-1] == '\n' {
+		return 1
+	}
+	return 0
+}
+
+// containerRenderer renders container blocks as <aside class="msg"> or <details>
+type containerRenderer struct{}
+
+// RegisterFuncs implements renderer.NodeRenderer
+func (r *containerRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindContainer, r.renderContainer)
+}
+
+// renderContainer writes the opening or closing tags around the rendered children
+func (r *containerRenderer) renderContainer(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*containerNode)
+	switch {
+	case n.Name == "details" && entering:
+		_, _ = w.WriteString("<details>\n<summary>" + escapeHTML(string(n.Title)) + "</summary>\n")
+	case n.Name == "details":
+		_, _ = w.WriteString("</details>\n")
+	case entering && n.Alert:
+		_, _ = w.WriteString(`<aside class="msg alert">` + "\n")
+	case entering:
+		_, _ = w.WriteString(`<aside class="msg">` + "\n")
+	default:
+		_, _ = w.WriteString("</aside>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+// containerExtension is a goldmark extension for Zenn-style ::: message and details blocks
+type containerExtension struct{}
+
+// Extend implements goldmark.Extender
+func (e *containerExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithBlockParsers(
+		util.Prioritized(&containerParser{}, 150),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&containerRenderer{}, 100),
+	))
+}
@@ -0,0 +1,48 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContainer(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{
+			name:   "message",
+			source: ":::message alert\n**注意**\n:::\n\nafter\n",
+			want:   []string{`<aside class="msg alert">`, "<strong>注意</strong>", "</aside>\n<p>after</p>"},
+		},
+		{
+			name:   "nested",
+			source: "::::details タイトル\n:::message\ninner\n:::\nouter\n::::\n",
+			want:   []string{"<summary>タイトル</summary>", `<aside class="msg">`, "</aside>\n<p>outer</p>\n</details>"},
+		},
+		{
+			name:   "colons in a code block",
+			source: ":::message\n```markdown\n:::message\n:::\n```\nafter code\n:::\n\noutside\n",
+			want:   []string{`<span class="cl">:::message`, `<span class="cl">:::`, "</code></pre>\n<p>after code</p>\n</aside>\n<p>outside</p>"},
+		},
+		{
+			name:   "colons in a longer tilde fence",
+			source: "::::details 例\n~~~~\n```\n::::\n~~~\n~~~~\n::::\n",
+			want:   []string{`<span class="cl">::::`, `<span class="cl">~~~`, "</code></pre>\n</details>"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article, err := NewParser().Parse([]byte(tt.source), "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(article.Content, want) {
+					t.Errorf("missing %q in:\n%s", want, article.Content)
+				}
+			}
+		})
+	}
+}
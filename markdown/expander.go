@@ -1,92 +1,254 @@
 package markdown
 
 import (
+	"context"
+	"log"
 	"regexp"
 	"strings"
+	"sync"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
 )
 
-// Expander handles expansion of special URLs in markdown content
-type Expander struct {
-	github  *GitHubCodeExpander
-	ogcard  *OGCardFetcher
-	twitter *TwitterEmbedder
+// Embed is the content a URL is expanded into.
+// HTML is written to the output as is; otherwise OG is rendered as an OG card,
+// or Code as a code block. Embeds are recorded as is in the EmbedLock.
+type Embed struct {
+	HTML     string  `json:"html,omitempty"`
+	OG       *OGData `json:"og,omitempty"`
+	Code     string  `json:"code,omitempty"`
+	Language string  `json:"language,omitempty"` // language of Code, used for the code block class
+}
+
+// Provider expands URLs of one kind into embeds
+type Provider interface {
+	// Match reports whether the provider handles url
+	Match(url string) bool
+	// Expand fetches the content for url
+	Expand(ctx context.Context, url string) (Embed, error)
 }
 
-// NewExpander creates a new Expander with all sub-expanders
-func NewExpander() *Expander {
-	return &Expander{
-		github:  NewGitHubCodeExpander(),
-		ogcard:  NewOGCardFetcher(),
-		twitter: NewTwitterEmbedder(),
+// DefaultProviders returns the built-in providers in the order they are tried,
+// all fetching with fetcher. The OG card fetcher matches every URL, so it comes last.
+func DefaultProviders(fetcher *Fetcher) []Provider {
+	return []Provider{
+		NewGitHubCodeExpander(fetcher),
+		NewTwitterEmbedder(fetcher),
+		NewOGCardFetcher(fetcher),
 	}
 }
 
-// urlOnlyLinePattern matches lines that contain only a URL
-var urlOnlyLinePattern = regexp.MustCompile(`^\s*(https?://[^\s]+)\s*$`)
+// Expander is a registry of providers that expands special URLs in markdown content
+type Expander struct {
+	providers []Provider
+	lock      *EmbedLock
+	lockMode  LockMode
+}
 
-// ExpandContent processes markdown content and expands special URLs
-// This should be called BEFORE parsing with goldmark
-func (e *Expander) ExpandContent(content string) string {
-	lines := strings.Split(content, "\n")
-	var result []string
-	inCodeBlock := false
+// NewExpander creates a new Expander that tries providers in order
+func NewExpander(providers ...Provider) *Expander {
+	return &Expander{providers: providers}
+}
 
-	for i := 0; i < len(lines); i++ {
-		line := lines[i]
+// Expand expands url with the first matching provider that succeeds.
+// With an EmbedLock, locked embeds are reused according to the LockMode.
+// It returns false if the URL could not be expanded, leaving it as a plain link.
+func (e *Expander) Expand(ctx context.Context, url string) (Embed, bool) {
+	if e.lock == nil {
+		return e.fetch(ctx, url)
+	}
 
-		// Track code blocks to avoid processing URLs inside them
-		if strings.HasPrefix(strings.TrimSpace(line), "```") {
-			inCodeBlock = !inCodeBlock
-			result = append(result, line)
-			continue
+	locked, ok := e.lock.Lookup(url)
+	switch {
+	case e.lockMode == LockOffline:
+		if !ok {
+			log.Printf("No locked embed for %s, leaving it as a link", url)
 		}
+		return locked, ok
+	case ok && e.lockMode != LockRefresh:
+		return locked, true
+	}
 
-		if inCodeBlock {
-			result = append(result, line)
-			continue
+	embed, fetched := e.fetch(ctx, url)
+	if !fetched {
+		if ok {
+			log.Printf("Failed to refresh embed for %s, using the locked one", url)
 		}
+		return locked, ok
+	}
+	e.lock.put(url, embed)
+	return embed, true
+}
 
-		// Check if line is a URL-only line
-		if match := urlOnlyLinePattern.FindStringSubmatch(line); match != nil {
-			url := match[1]
-			expanded := e.expandURL(url)
-			result = append(result, expanded)
-		} else {
-			result = append(result, line)
+// fetch expands url with the providers
+func (e *Expander) fetch(ctx context.Context, url string) (Embed, bool) {
+	for _, p := range e.providers {
+		if !p.Match(url) {
+			continue
+		}
+		embed, err := p.Expand(ctx, url)
+		if err != nil {
+			// Fall through to the next provider on error
+			continue
 		}
+		return embed, true
 	}
+	return Embed{}, false
+}
+
+// expansion is the parser context value enabling URL expansion for a conversion
+type expansion struct {
+	ctx      context.Context
+	expander *Expander
+	// embeds records each standalone URL and its embed (nil if left as a link)
+	embeds map[string]*Embed
+}
+
+// expanderKey is the parser context key holding the expansion for a conversion.
+// URLs are only expanded when it is set (see Parser.ParseWithExpansion).
+var expanderKey = parser.NewContextKey()
+
+// kindEmbed is the NodeKind of embedNode
+var kindEmbed = ast.NewNodeKind("Embed")
+
+// embedNode is a block node holding the embed an URL was expanded into
+type embedNode struct {
+	ast.BaseBlock
+	Embed Embed
+}
+
+// Kind implements ast.Node
+func (n *embedNode) Kind() ast.NodeKind {
+	return kindEmbed
+}
 
-	return strings.Join(result, "\n")
+// Dump implements ast.Node
+func (n *embedNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Language": n.Embed.Language}, nil)
 }
 
-// expandURL expands a URL based on its type
-func (e *Expander) expandURL(url string) string {
-	// Check if it's a GitHub URL
-	if _, ok := ParseGitHubURL(url); ok {
-		expanded, err := e.github.ExpandToCodeBlock(url)
-		if err == nil {
-			return expanded
+// expandTransformer replaces paragraphs consisting of a single URL autolink with embeds.
+// Since it works on the parsed document, URLs in code blocks, inline text,
+// tight list items and the frontmatter are never expanded.
+type expandTransformer struct{}
+
+// Transform implements parser.ASTTransformer
+func (t *expandTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	exp, ok := pc.Get(expanderKey).(*expansion)
+	if !ok {
+		return
+	}
+	source := reader.Source()
+
+	type target struct {
+		paragraph *ast.Paragraph
+		url       string
+	}
+	var targets []target
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if p, ok := n.(*ast.Paragraph); ok {
+			if url := standaloneURL(p, source); url != "" {
+				targets = append(targets, target{paragraph: p, url: url})
+			}
+			return ast.WalkSkipChildren, nil
 		}
-		// Fall through to OG card on error
+		return ast.WalkContinue, nil
+	})
+
+	// Resolve every distinct URL concurrently
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		resolved = make(map[string]Embed)
+		seen     = make(map[string]bool)
+	)
+	for _, tg := range targets {
+		if seen[tg.url] {
+			continue
+		}
+		seen[tg.url] = true
+		wg.Go(func() {
+			if embed, ok := exp.expander.Expand(exp.ctx, tg.url); ok {
+				mu.Lock()
+				resolved[tg.url] = embed
+				mu.Unlock()
+			}
+		})
 	}
+	wg.Wait()
 
-	// Check if it's a Twitter/X URL
-	if IsTwitterURL(url) {
-		expanded, err := e.twitter.GenerateEmbed(url)
-		if err == nil {
-			return expanded
+	exp.embeds = make(map[string]*Embed, len(seen))
+	for url := range seen {
+		if embed, ok := resolved[url]; ok {
+			exp.embeds[url] = &embed
+		} else {
+			exp.embeds[url] = nil
 		}
-		// Fall through to OG card on error
 	}
 
-	// Default: generate OG card
-	card, err := e.ogcard.GenerateOGCard(url)
-	if err == nil {
-		return card
+	// Replace after walking so the tree is not modified during traversal
+	for _, tg := range targets {
+		if embed, ok := resolved[tg.url]; ok {
+			parent := tg.paragraph.Parent()
+			parent.ReplaceChild(parent, tg.paragraph, &embedNode{Embed: embed})
+		}
 	}
+}
 
-	// If all else fails, return as a simple link
-	return "[" + url + "](" + url + ")"
+// standaloneURL returns the URL if the paragraph contains nothing but an http(s) autolink
+func standaloneURL(p *ast.Paragraph, source []byte) string {
+	if p.ChildCount() != 1 {
+		return ""
+	}
+	link, ok := p.FirstChild().(*ast.AutoLink)
+	if !ok || link.AutoLinkType != ast.AutoLinkURL {
+		return ""
+	}
+	url := string(link.URL(source))
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return ""
+	}
+	return url
+}
+
+// embedRenderer renders the embed nodes created by expandTransformer
+type embedRenderer struct{}
+
+// RegisterFuncs implements renderer.NodeRenderer
+func (r *embedRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindEmbed, r.renderEmbed)
+}
+
+// renderEmbed writes the embed HTML as is, renders its OG card,
+// or highlights its code the same way as a fenced code block
+func (r *embedRenderer) renderEmbed(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	embed := node.(*embedNode).Embed
+	switch {
+	case embed.HTML != "":
+		_, _ = w.WriteString(embed.HTML)
+		_ = w.WriteByte('\n')
+		return ast.WalkContinue, nil
+	case embed.OG != nil:
+		_, _ = w.WriteString(RenderOGCard(embed.OG))
+		_ = w.WriteByte('\n')
+		return ast.WalkContinue, nil
+	}
+
+	if err := writeCode(w, fenceInfo{language: embed.Language}, strings.TrimRight(embed.Code, "\n")+"\n"); err != nil {
+		return ast.WalkStop, err
+	}
+	_ = w.WriteByte('\n')
+	return ast.WalkContinue, nil
 }
 
 // imageWidthPattern matches custom image width syntax: ![alt](url){width=500}
@@ -0,0 +1,174 @@
+package markdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Fetcher performs the HTTP requests of the embed providers.
+// The fields may be changed before the first request.
+type Fetcher struct {
+	Client      *http.Client
+	UserAgent   string
+	Timeout     time.Duration // per attempt
+	MaxRetries  int           // retries after the first attempt on network errors, 5xx and 429
+	RetryWait   time.Duration // wait before the first retry, doubled for each further retry
+	MaxBodySize int64         // larger responses are an error
+	MaxPerHost  int           // concurrent requests per host
+
+	mu    sync.Mutex
+	hosts map[string]chan struct{}
+}
+
+// NewFetcher creates a Fetcher with the default limits
+func NewFetcher() *Fetcher {
+	return &Fetcher{
+		Client:      &http.Client{},
+		UserAgent:   "Mozilla/5.0 (compatible; ujiprog-bot/1.0; +https://ujiprog.com)",
+		Timeout:     10 * time.Second,
+		MaxRetries:  2,
+		RetryWait:   500 * time.Millisecond,
+		MaxBodySize: 5 << 20,
+		MaxPerHost:  2,
+	}
+}
+
+// maxRetryAfter caps the wait requested by a Retry-After header
+const maxRetryAfter = 30 * time.Second
+
+// statusError is returned for non-200 responses
+type statusError struct {
+	url        string
+	statusCode int
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("GET %s: status %d", e.url, e.statusCode)
+}
+
+// errBodyTooLarge is returned for responses over MaxBodySize, which retrying cannot fix
+var errBodyTooLarge = errors.New("response too large")
+
+// retryable reports whether the request may succeed when retried
+func (e *statusError) retryable() bool {
+	return e.statusCode >= 500 || e.statusCode == http.StatusTooManyRequests
+}
+
+// Get fetches rawURL and returns the response body.
+// Responses other than 200 OK are errors.
+func (f *Fetcher) Get(ctx context.Context, rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := f.acquire(ctx, u.Host)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	wait := f.RetryWait
+	for attempt := 0; ; attempt++ {
+		body, err := f.get(ctx, rawURL)
+		if err == nil {
+			return body, nil
+		}
+
+		var statusErr *statusError
+		retryable := ctx.Err() == nil && !errors.Is(err, errBodyTooLarge)
+		if errors.As(err, &statusErr) {
+			retryable = retryable && statusErr.retryable()
+			if statusErr.retryAfter > wait {
+				wait = min(statusErr.retryAfter, maxRetryAfter)
+			}
+		}
+		if !retryable || attempt >= f.MaxRetries {
+			return nil, err
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		wait *= 2
+	}
+}
+
+// get performs a single attempt
+func (f *Fetcher) get(ctx context.Context, rawURL string) ([]byte, error) {
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.UserAgent != "" {
+		req.Header.Set("User-Agent", f.UserAgent)
+	}
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		statusErr := &statusError{url: rawURL, statusCode: resp.StatusCode}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			statusErr.retryAfter = time.Duration(seconds) * time.Second
+		}
+		return nil, statusErr
+	}
+
+	body := io.Reader(resp.Body)
+	if f.MaxBodySize > 0 {
+		body = io.LimitReader(resp.Body, f.MaxBodySize+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if f.MaxBodySize > 0 && int64(len(data)) > f.MaxBodySize {
+		return nil, fmt.Errorf("GET %s: %w: exceeds %d bytes", rawURL, errBodyTooLarge, f.MaxBodySize)
+	}
+	return data, nil
+}
+
+// acquire waits for a free slot for host and returns the function releasing it
+func (f *Fetcher) acquire(ctx context.Context, host string) (func(), error) {
+	if f.MaxPerHost <= 0 {
+		return func() {}, nil
+	}
+
+	f.mu.Lock()
+	if f.hosts == nil {
+		f.hosts = make(map[string]chan struct{})
+	}
+	sem, ok := f.hosts[host]
+	if !ok {
+		sem = make(chan struct{}, f.MaxPerHost)
+		f.hosts[host] = sem
+	}
+	f.mu.Unlock()
+
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,125 @@
+package markdown
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newTestFetcher returns a Fetcher without waits between retries
+func newTestFetcher() *Fetcher {
+	f := NewFetcher()
+	f.RetryWait = 0
+	return f
+}
+
+func TestFetcherRetriesServerErrors(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	body, err := newTestFetcher().Get(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "ok" || requests.Load() != 3 {
+		t.Errorf("got %q after %d requests", body, requests.Load())
+	}
+}
+
+func TestFetcherDoesNotRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"not found", func(w http.ResponseWriter, r *http.Request) {
+			http.NotFound(w, r)
+		}},
+		{"oversized body", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(strings.Repeat("x", 100)))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				tt.handler(w, r)
+			}))
+			defer srv.Close()
+
+			f := newTestFetcher()
+			f.MaxBodySize = 10
+			if _, err := f.Get(context.Background(), srv.URL); err == nil {
+				t.Fatal("expected an error")
+			}
+			if requests.Load() != 1 {
+				t.Errorf("got %d requests, want 1", requests.Load())
+			}
+		})
+	}
+}
+
+func TestFetcherBodySizeError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 11)))
+	}))
+	defer srv.Close()
+
+	f := newTestFetcher()
+	f.MaxBodySize = 10
+	if _, err := f.Get(context.Background(), srv.URL); !errors.Is(err, errBodyTooLarge) {
+		t.Errorf("got %v, want errBodyTooLarge", err)
+	}
+}
+
+// redirectTransport sends every request to the test server
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestParserUsesInjectedFetcher(t *testing.T) {
+	var userAgent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		w.Write([]byte(`<html><head><meta property="og:title" content="Example Page"></head></html>`))
+	}))
+	defer srv.Close()
+
+	target, _ := url.Parse(srv.URL)
+	f := newTestFetcher()
+	f.Client = &http.Client{Transport: &redirectTransport{target: target}}
+	f.UserAgent = "test-agent"
+
+	article, err := NewParser(WithFetcher(f)).ParseWithExpansion([]byte("https://example.com/page\n"), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(article.Content, "Example Page") {
+		t.Errorf("OG card not rendered: %s", article.Content)
+	}
+	if userAgent != "test-agent" {
+		t.Errorf("User-Agent %q", userAgent)
+	}
+	if article.Embeds["https://example.com/page"] == nil {
+		t.Errorf("embed not recorded: %v", article.Embeds)
+	}
+}
@@ -0,0 +1,143 @@
+package markdown
+
+import (
+	"strconv"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// footnotesHeading is the heading of the footnotes section
+const footnotesHeading = "脚注"
+
+// footnoteIDTransformer assigns the ids of footnotes, their references and the
+// footnotes heading through the parser's IDs, so they never collide with heading ids
+// (see japaneseIDs). It runs after the footnote extension has numbered the footnotes.
+type footnoteIDTransformer struct{}
+
+// Transform implements parser.ASTTransformer
+func (t *footnoteIDTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	ids := pc.IDs()
+
+	var list *east.FootnoteList
+	for n := doc.LastChild(); n != nil; n = n.PreviousSibling() {
+		if l, ok := n.(*east.FootnoteList); ok {
+			list = l
+			break
+		}
+	}
+	if list == nil {
+		return
+	}
+	list.SetAttributeString("aria-labelledby", ids.Generate([]byte(footnotesHeading), east.KindFootnoteList))
+
+	// Footnotes first, since references point to them
+	noteIDs := make(map[int][]byte)
+	for n := list.FirstChild(); n != nil; n = n.NextSibling() {
+		note := n.(*east.Footnote)
+		noteIDs[note.Index] = ids.Generate([]byte("fn-"+strconv.Itoa(note.Index)), east.KindFootnote)
+		note.SetAttributeString("id", noteIDs[note.Index])
+	}
+
+	type ref struct{ index, refIndex int }
+	refIDs := make(map[ref][]byte)
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if link, ok := n.(*east.FootnoteLink); ok && entering {
+			id := ids.Generate([]byte("fnref-"+strconv.Itoa(link.Index)), east.KindFootnoteLink)
+			refIDs[ref{link.Index, link.RefIndex}] = id
+			link.SetAttributeString("id", id)
+			link.SetAttributeString("href", noteIDs[link.Index])
+		}
+		return ast.WalkContinue, nil
+	})
+	_ = ast.Walk(list, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if backlink, ok := n.(*east.FootnoteBacklink); ok && entering {
+			backlink.SetAttributeString("href", refIDs[ref{backlink.Index, backlink.RefIndex}])
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// footnoteRenderer renders footnotes with the ids set by footnoteIDTransformer.
+// References carry data-footnote with the id of their footnote for the popovers in article.js.
+type footnoteRenderer struct{}
+
+// RegisterFuncs implements renderer.NodeRenderer
+func (r *footnoteRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(east.KindFootnoteLink, r.renderFootnoteLink)
+	reg.Register(east.KindFootnoteBacklink, r.renderFootnoteBacklink)
+	reg.Register(east.KindFootnote, r.renderFootnote)
+	reg.Register(east.KindFootnoteList, r.renderFootnoteList)
+}
+
+// attribute returns the string value of a node attribute set by footnoteIDTransformer
+func attribute(n ast.Node, name string) string {
+	value, _ := n.AttributeString(name)
+	b, _ := value.([]byte)
+	return escapeHTML(string(b))
+}
+
+// renderFootnoteLink writes a numbered reference to a footnote
+func (r *footnoteRenderer) renderFootnoteLink(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*east.FootnoteLink)
+	noteID := attribute(n, "href")
+	_, _ = w.WriteString(`<sup class="footnote-ref" id="` + attribute(n, "id") + `">`)
+	_, _ = w.WriteString(`<a href="#` + noteID + `" role="doc-noteref" data-footnote="` + noteID + `">`)
+	_, _ = w.WriteString(strconv.Itoa(n.Index) + "</a></sup>")
+	return ast.WalkContinue, nil
+}
+
+// renderFootnoteBacklink writes the link from a footnote back to one of its references
+func (r *footnoteRenderer) renderFootnoteBacklink(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	_, _ = w.WriteString(`&#160;<a href="#` + attribute(node, "href") + `" class="footnote-backref" role="doc-backlink" aria-label="本文に戻る">&#x21a9;&#xfe0e;</a>`)
+	return ast.WalkContinue, nil
+}
+
+// renderFootnote writes a footnote as an item of the footnotes section
+func (r *footnoteRenderer) renderFootnote(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString(`<li id="` + attribute(node, "id") + `">` + "\n")
+	} else {
+		_, _ = w.WriteString("</li>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+// renderFootnoteList writes the footnotes section at the end of the article
+func (r *footnoteRenderer) renderFootnoteList(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		headingID := attribute(node, "aria-labelledby")
+		_, _ = w.WriteString(`<section class="footnotes" role="doc-endnotes" aria-labelledby="` + headingID + `">` + "\n")
+		_, _ = w.WriteString(`<h2 id="` + headingID + `">` + footnotesHeading + "</h2>\n<ol>\n")
+	} else {
+		_, _ = w.WriteString("</ol>\n</section>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+// footnoteExtension adds footnotes ([^1]) whose ids coexist with heading ids,
+// rendered into a "脚注" section with back-links
+type footnoteExtension struct{}
+
+// Extend implements goldmark.Extender
+func (e *footnoteExtension) Extend(m goldmark.Markdown) {
+	extension.Footnote.Extend(m)
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(&footnoteIDTransformer{}, 1000),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&footnoteRenderer{}, 100),
+	))
+}
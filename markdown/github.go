@@ -1,9 +1,8 @@
 package markdown
 
 import (
+	"context"
 	"fmt"
-	"io"
-	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
@@ -11,13 +10,13 @@ import (
 
 // GitHubCodeExpander handles expansion of GitHub blob URLs to code blocks
 type GitHubCodeExpander struct {
-	client *http.Client
+	fetcher *Fetcher
 }
 
-// NewGitHubCodeExpander creates a new GitHubCodeExpander
-func NewGitHubCodeExpander() *GitHubCodeExpander {
+// NewGitHubCodeExpander creates a new GitHubCodeExpander that fetches with fetcher
+func NewGitHubCodeExpander(fetcher *Fetcher) *GitHubCodeExpander {
 	return &GitHubCodeExpander{
-		client: &http.Client{},
+		fetcher: fetcher,
 	}
 }
 
@@ -66,25 +65,15 @@ func ParseGitHubURL(url string) (*GitHubURLInfo, bool) {
 }
 
 // FetchCode fetches code from a GitHub raw URL and extracts the specified lines
-func (g *GitHubCodeExpander) FetchCode(info *GitHubURLInfo) (string, error) {
+func (g *GitHubCodeExpander) FetchCode(ctx context.Context, info *GitHubURLInfo) (string, error) {
 	rawURL := fmt.Sprintf(
 		"https://raw.githubusercontent.com/%s/%s/%s/%s",
 		info.Owner, info.Repo, info.Ref, info.Path,
 	)
 
-	resp, err := g.client.Get(rawURL)
+	body, err := g.fetcher.Get(ctx, rawURL)
 	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to fetch code: status %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+		return "", fmt.Errorf("failed to fetch code: %w", err)
 	}
 
 	content := string(body)
@@ -143,19 +132,20 @@ func GetLanguage(path string) string {
 	return ""
 }
 
-// ExpandToCodeBlock fetches code and returns a markdown code block
-func (g *GitHubCodeExpander) ExpandToCodeBlock(url string) (string, error) {
+// ExpandToCodeBlock fetches code and returns its language and the code block content
+// headed by a "// repo/path (Lx-Ly)" comment
+func (g *GitHubCodeExpander) ExpandToCodeBlock(ctx context.Context, url string) (lang, code string, err error) {
 	info, ok := ParseGitHubURL(url)
 	if !ok {
-		return "", fmt.Errorf("invalid GitHub URL: %s", url)
+		return "", "", fmt.Errorf("invalid GitHub URL: %s", url)
 	}
 
-	code, err := g.FetchCode(info)
+	code, err = g.FetchCode(ctx, info)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	lang := GetLanguage(info.Path)
+	lang = GetLanguage(info.Path)
 	lineInfo := ""
 	if info.StartLine > 0 {
 		if info.EndLine > info.StartLine {
@@ -165,6 +155,20 @@ func (g *GitHubCodeExpander) ExpandToCodeBlock(url string) (string, error) {
 		}
 	}
 
-	return fmt.Sprintf("```%s\n// %s/%s%s\n%s\n```",
-		lang, info.Repo, info.Path, lineInfo, code), nil
+	return lang, fmt.Sprintf("// %s/%s%s\n%s", info.Repo, info.Path, lineInfo, code), nil
+}
+
+// Match implements Provider for GitHub blob URLs
+func (g *GitHubCodeExpander) Match(url string) bool {
+	_, ok := ParseGitHubURL(url)
+	return ok
+}
+
+// Expand implements Provider by expanding the URL to a code block
+func (g *GitHubCodeExpander) Expand(ctx context.Context, url string) (Embed, error) {
+	lang, code, err := g.ExpandToCodeBlock(ctx, url)
+	if err != nil {
+		return Embed{}, err
+	}
+	return Embed{Code: code, Language: lang}, nil
 }
@@ -0,0 +1,210 @@
+package markdown
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// highlightStyle is the chroma style of the generated stylesheet
+const highlightStyle = "monokai"
+
+// fenceInfo is the parsed info string of a fenced code block.
+// Besides the language it supports Zenn's filename labels ("go:main.go"),
+// diff blocks ("diff go") and highlighted lines ("go {3-5}").
+type fenceInfo struct {
+	language string
+	filename string
+	// diff marks a diff of language code whose lines start with "+", "-" or " "
+	diff bool
+	// highlight lists the 1-based inclusive line ranges to highlight
+	highlight [][2]int
+}
+
+// parseFenceInfo parses an info string such as "diff go:main.go {1,3-5}".
+// Malformed line ranges are ignored.
+func parseFenceInfo(info string) fenceInfo {
+	var fi fenceInfo
+	info = strings.TrimSpace(info)
+	if i := strings.IndexByte(info, '{'); i >= 0 && strings.HasSuffix(info, "}") {
+		fi.highlight = parseLineRanges(info[i+1 : len(info)-1])
+		info = info[:i]
+	}
+
+	fields := strings.Fields(info)
+	if len(fields) >= 2 && fields[0] == "diff" {
+		// "diff" alone is the diff language itself
+		fi.diff = true
+		fields = fields[1:]
+	}
+	if len(fields) > 0 {
+		fi.language, fi.filename, _ = strings.Cut(fields[0], ":")
+	}
+	return fi
+}
+
+// parseLineRanges parses comma-separated line numbers and ranges such as "1,3-5"
+func parseLineRanges(s string) [][2]int {
+	var ranges [][2]int
+	for _, part := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		start, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil || start < 1 {
+			continue
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(strings.TrimSpace(to)); err != nil || end < start {
+				continue
+			}
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges
+}
+
+// highlighted reports whether the 1-based line is in one of the highlight ranges
+func (fi fenceInfo) highlighted(line int) bool {
+	for _, r := range fi.highlight {
+		if line >= r[0] && line <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// diffLineClasses maps the first character of a diff line to the class of its line
+var diffLineClasses = map[byte]string{
+	'+': "diff-add",
+	'-': "diff-remove",
+}
+
+// writeCode writes code highlighted for info.language with line numbers,
+// wrapped in a labeled block if it has a filename.
+// Tokens and lines are marked up with classes only (see WriteHighlightCSS),
+// so no inline styles are emitted. Unknown languages are written as plain text.
+func writeCode(w io.Writer, info fenceInfo, code string) error {
+	// Strip the diff marks, highlighting the code itself
+	var lineClasses []string
+	if info.diff {
+		lines := strings.SplitAfter(code, "\n")
+		lineClasses = make([]string, len(lines))
+		for i, line := range lines {
+			if line == "" || line == "\n" {
+				continue
+			}
+			switch line[0] {
+			case '+', '-', ' ':
+				lineClasses[i] = diffLineClasses[line[0]]
+				lines[i] = line[1:]
+			}
+		}
+		code = strings.Join(lines, "")
+	}
+
+	lexer := lexers.Get(info.language)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return err
+	}
+	lines := chroma.SplitTokensIntoLines(iterator.Tokens())
+
+	var b strings.Builder
+	if info.filename != "" {
+		b.WriteString(`<div class="code-block"><div class="code-block-filename">` + escapeHTML(info.filename) + "</div>")
+	}
+	b.WriteString(`<pre class="chroma"><code`)
+	if info.language != "" {
+		b.WriteString(` class="language-` + escapeHTML(info.language) + `"`)
+	}
+	b.WriteByte('>')
+
+	lineDigits := len(strconv.Itoa(len(lines)))
+	for i, tokens := range lines {
+		b.WriteString(`<span class="line`)
+		if info.highlighted(i + 1) {
+			b.WriteString(" hl")
+		}
+		if i < len(lineClasses) && lineClasses[i] != "" {
+			b.WriteString(" " + lineClasses[i])
+		}
+		fmt.Fprintf(&b, `"><span class="ln">%*d</span><span class="cl">`, lineDigits, i+1)
+		for _, token := range tokens {
+			if class := tokenClass(token.Type); class != "" {
+				b.WriteString(`<span class="` + class + `">` + escapeHTML(token.Value) + "</span>")
+			} else {
+				b.WriteString(escapeHTML(token.Value))
+			}
+		}
+		b.WriteString("</span></span>")
+	}
+
+	b.WriteString("</code></pre>")
+	if info.filename != "" {
+		b.WriteString("</div>")
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// tokenClass returns the chroma class of a token type, falling back to its parent types
+func tokenClass(t chroma.TokenType) string {
+	for ; t != 0; t = t.Parent() {
+		if class, ok := chroma.StandardTypes[t]; ok {
+			return class
+		}
+	}
+	return chroma.StandardTypes[t]
+}
+
+// WriteHighlightCSS writes the stylesheet for the classes of highlighted code blocks
+func WriteHighlightCSS(w io.Writer) error {
+	formatter := chromahtml.New(chromahtml.WithClasses(true), chromahtml.WithLineNumbers(true))
+	return formatter.WriteCSS(w, styles.Get(highlightStyle))
+}
+
+// codeBlockRenderer renders fenced code blocks with syntax highlighting
+type codeBlockRenderer struct{}
+
+// RegisterFuncs implements renderer.NodeRenderer
+func (r *codeBlockRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.renderFencedCodeBlock)
+}
+
+// renderFencedCodeBlock highlights the block according to its info string (see fenceInfo)
+func (r *codeBlockRenderer) renderFencedCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.FencedCodeBlock)
+
+	var info fenceInfo
+	if n.Info != nil {
+		info = parseFenceInfo(string(n.Info.Segment.Value(source)))
+	}
+
+	var code strings.Builder
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		code.Write(line.Value(source))
+	}
+
+	if err := writeCode(w, info, code.String()); err != nil {
+		return ast.WalkStop, err
+	}
+	_ = w.WriteByte('\n')
+	return ast.WalkContinue, nil
+}
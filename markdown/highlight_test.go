@@ -0,0 +1,130 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFenceInfo(t *testing.T) {
+	tests := []struct {
+		info string
+		want fenceInfo
+	}{
+		{"", fenceInfo{}},
+		{"go", fenceInfo{language: "go"}},
+		{"go:main.go", fenceInfo{language: "go", filename: "main.go"}},
+		{"diff go", fenceInfo{language: "go", diff: true}},
+		{"diff go:main.go", fenceInfo{language: "go", filename: "main.go", diff: true}},
+		// "diff" alone is the diff language itself
+		{"diff", fenceInfo{language: "diff"}},
+		{"go {1,3-5}", fenceInfo{language: "go", highlight: [][2]int{{1, 1}, {3, 5}}}},
+		{"go{2}", fenceInfo{language: "go", highlight: [][2]int{{2, 2}}}},
+		{"diff go:main.go {1, 3 - 5}", fenceInfo{language: "go", filename: "main.go", diff: true, highlight: [][2]int{{1, 1}, {3, 5}}}},
+		// Malformed ranges are ignored, keeping the valid ones
+		{"go {0,x,5-3,2-,4}", fenceInfo{language: "go", highlight: [][2]int{{4, 4}}}},
+		{"go {}", fenceInfo{language: "go"}},
+		// Without the closing brace the braces are not a line range
+		{"go {1", fenceInfo{language: "go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.info, func(t *testing.T) {
+			if got := parseFenceInfo(tt.info); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFenceInfo(%q) = %+v, want %+v", tt.info, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		info    string
+		code    string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "filename label",
+			info: "go:<main>.go",
+			code: "package main\n",
+			want: []string{
+				`<div class="code-block"><div class="code-block-filename">&lt;main&gt;.go</div><pre class="chroma"><code class="language-go">`,
+				"</code></pre></div>",
+			},
+		},
+		{
+			name:    "without a filename",
+			info:    "go",
+			code:    "package main\n",
+			want:    []string{`<pre class="chroma"><code class="language-go">`},
+			notWant: []string{"code-block"},
+		},
+		{
+			name: "diff go",
+			info: "diff go",
+			code: " package main\n-var x = 1\n+var x = 2\n",
+			want: []string{
+				`<span class="line"><span class="ln">1</span><span class="cl"><span class="kn">package</span>`,
+				`<span class="line diff-remove"><span class="ln">2</span><span class="cl"><span class="kd">var</span>`,
+				`<span class="line diff-add"><span class="ln">3</span><span class="cl"><span class="kd">var</span>`,
+			},
+			notWant: []string{"+", "-var"},
+		},
+		{
+			name: "bare diff",
+			info: "diff",
+			code: "-old\n+new\n",
+			want: []string{
+				`<code class="language-diff">`,
+				`<span class="line"><span class="ln">1</span><span class="cl"><span class="gd">-old`,
+				`<span class="line"><span class="ln">2</span><span class="cl"><span class="gi">+new`,
+			},
+			notWant: []string{"diff-add", "diff-remove"},
+		},
+		{
+			name: "highlighted lines",
+			info: "text {1,3-4}",
+			code: "a\nb\nc\nd\ne\n",
+			want: []string{
+				`<span class="line hl"><span class="ln">1</span>`,
+				`<span class="line"><span class="ln">2</span>`,
+				`<span class="line hl"><span class="ln">3</span>`,
+				`<span class="line hl"><span class="ln">4</span>`,
+				`<span class="line"><span class="ln">5</span>`,
+			},
+		},
+		{
+			name: "line numbers are padded",
+			info: "text",
+			code: strings.Repeat("x\n", 10),
+			want: []string{`<span class="ln"> 1</span>`, `<span class="ln">10</span>`},
+		},
+		{
+			name:    "unknown language",
+			info:    "nosuchlanguage",
+			code:    "<b>\n",
+			want:    []string{`<code class="language-nosuchlanguage">`, "&lt;b&gt;"},
+			notWant: []string{"style="},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := writeCode(&b, parseFenceInfo(tt.info), tt.code); err != nil {
+				t.Fatal(err)
+			}
+			got := b.String()
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("missing %q in\n%s", want, got)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("unexpected %q in\n%s", notWant, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package markdown
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// LockMode controls how an EmbedLock is used while expanding URLs
+type LockMode int
+
+const (
+	// LockReuse uses locked embeds and fetches only URLs missing from the lock
+	LockReuse LockMode = iota
+	// LockRefresh fetches every URL again, keeping the locked embed if fetching fails
+	LockRefresh
+	// LockOffline never fetches; URLs missing from the lock are left as links
+	LockOffline
+)
+
+// EmbedLock records the embeds fetched for each URL so that rebuilds are
+// reproducible and possible without network access
+type EmbedLock struct {
+	mu      sync.Mutex
+	embeds  map[string]Embed
+	changed bool
+}
+
+// embedLockFile is the JSON layout of the lockfile
+type embedLockFile struct {
+	Embeds map[string]Embed `json:"embeds"`
+}
+
+// LoadEmbedLock reads a lockfile. A missing file yields an empty lock.
+func LoadEmbedLock(path string) (*EmbedLock, error) {
+	lock := &EmbedLock{embeds: make(map[string]Embed)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file embedLockFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if file.Embeds != nil {
+		lock.embeds = file.Embeds
+	}
+	return lock, nil
+}
+
+// Save writes the lock to path if any embed was added or changed
+func (l *EmbedLock) Save(path string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.changed {
+		return nil
+	}
+
+	// Map keys are sorted by encoding/json, so the output is stable
+	data, err := json.MarshalIndent(embedLockFile{Embeds: l.embeds}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal embed lock: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	l.changed = false
+	return nil
+}
+
+// Lookup returns the locked embed for url
+func (l *EmbedLock) Lookup(url string) (Embed, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	embed, ok := l.embeds[url]
+	return embed, ok
+}
+
+// Retain drops the embeds of URLs missing from used, so the lockfile only
+// holds embeds that some article still references
+func (l *EmbedLock) Retain(used map[string]bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for url := range l.embeds {
+		if !used[url] {
+			delete(l.embeds, url)
+			l.changed = true
+		}
+	}
+}
+
+// put records the embed for url
+func (l *EmbedLock) put(url string, embed Embed) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if old, ok := l.embeds[url]; ok && embedEqual(old, embed) {
+		return
+	}
+	l.embeds[url] = embed
+	l.changed = true
+}
+
+// embedEqual reports whether two embeds have the same content
+func embedEqual(a, b Embed) bool {
+	if a.HTML != b.HTML || a.Code != b.Code || a.Language != b.Language {
+		return false
+	}
+	if a.OG == nil || b.OG == nil {
+		return a.OG == b.OG
+	}
+	return *a.OG == *b.OG
+}
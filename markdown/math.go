@@ -0,0 +1,280 @@
+package markdown
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// kindMathInline is the NodeKind of mathInline
+var kindMathInline = ast.NewNodeKind("MathInline")
+
+// mathInline is a $...$ (or $$...$$) formula inside a paragraph
+type mathInline struct {
+	ast.BaseInline
+	// Segment is the TeX source between the delimiters
+	Segment text.Segment
+	// Display marks a $$...$$ formula, rendered in display style
+	Display bool
+}
+
+// Kind implements ast.Node
+func (n *mathInline) Kind() ast.NodeKind {
+	return kindMathInline
+}
+
+// Dump implements ast.Node
+func (n *mathInline) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"TeX": string(n.Segment.Value(source))}, nil)
+}
+
+// kindMathBlock is the NodeKind of mathBlock
+var kindMathBlock = ast.NewNodeKind("MathBlock")
+
+// mathBlock is a $$ formula block whose lines hold the TeX source
+type mathBlock struct {
+	ast.BaseBlock
+	// closed is set once the closing $$ has been read
+	closed bool
+	// open is the source offset of the opening $$
+	open int
+}
+
+// Kind implements ast.Node
+func (n *mathBlock) Kind() ast.NodeKind {
+	return kindMathBlock
+}
+
+// IsRaw implements ast.Node
+func (n *mathBlock) IsRaw() bool {
+	return true
+}
+
+// Dump implements ast.Node
+func (n *mathBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// mathInlineParser parses $...$ and $$...$$ within a line. Like pandoc, an opening $
+// must not be followed by a space and a closing $ must not be preceded by a space or
+// followed by a digit, so prices such as "$5 and $10" stay text. An opening $
+// followed by a number and a space is a price too, as in "$5 per month, and$";
+// such a formula is written without the space ($1+1$).
+type mathInlineParser struct{}
+
+// Trigger implements parser.InlineParser
+func (s *mathInlineParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+// Parse implements parser.InlineParser
+func (s *mathInlineParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	fence := 1
+	if len(line) > 1 && line[1] == '$' {
+		fence = 2
+	}
+	start := fence
+	if start >= len(line) || util.IsSpace(line[start]) {
+		return nil
+	}
+	if fence == 1 {
+		number := start
+		for number < len(line) && (isDigit(line[number]) || line[number] == '.' || line[number] == ',') {
+			number++
+		}
+		if number > start && number < len(line) && util.IsSpace(line[number]) {
+			return nil
+		}
+	}
+
+	for i := start; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+			continue
+		case '$':
+		default:
+			continue
+		}
+		// A formula cannot contain an unescaped $
+		if fence == 2 {
+			if i+1 >= len(line) || line[i+1] != '$' || i == start {
+				return nil
+			}
+		} else if util.IsSpace(line[i-1]) || i+1 < len(line) && isDigit(line[i+1]) {
+			return nil
+		}
+		block.Advance(i + fence)
+		return &mathInline{
+			Segment: text.NewSegment(segment.Start+start, segment.Start+i),
+			Display: fence == 2,
+		}
+	}
+	return nil
+}
+
+// mathBlockParser parses display formulas between lines starting and ending with $$:
+//
+//	$$
+//	e^{i\pi} + 1 = 0
+//	$$
+//
+// A formula may also fit on a single line ($$x^2$$).
+type mathBlockParser struct{}
+
+// Trigger implements parser.BlockParser
+func (b *mathBlockParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+// Open implements parser.BlockParser
+func (b *mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], []byte("$$")) {
+		return nil, parser.NoChildren
+	}
+
+	node := &mathBlock{open: segment.Start + pos}
+	rest := segment.WithStart(segment.Start + pos + 2)
+	if content := bytes.TrimRight(line[pos+2:], " \t\r\n"); bytes.HasSuffix(content, []byte("$$")) && len(content) >= 2 {
+		// Single-line $$x$$
+		node.Lines().Append(rest.WithStop(rest.Start + len(content) - 2))
+		node.closed = true
+	} else if !util.IsBlank(line[pos+2:]) {
+		// Left to the inline parser, as in "$$x$$ is ..."
+		return nil, parser.NoChildren
+	}
+	reader.Advance(segment.Len() - trailingNewline(line) + segment.Padding)
+	return node, parser.NoChildren
+}
+
+// Continue implements parser.BlockParser
+func (b *mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	n := node.(*mathBlock)
+	if n.closed {
+		return parser.Close
+	}
+	line, segment := reader.PeekLine()
+	if line == nil {
+		return parser.Close
+	}
+	content := bytes.TrimRight(line, " \t\r\n")
+	if bytes.HasSuffix(content, []byte("$$")) {
+		if len(bytes.TrimSpace(content)) > 2 {
+			n.Lines().Append(segment.WithStop(segment.Start + len(content) - 2))
+		}
+		reader.Advance(segment.Len() - trailingNewline(line) + segment.Padding)
+		n.closed = true
+		return parser.Close
+	}
+	n.Lines().Append(segment)
+	reader.Advance(segment.Len() - trailingNewline(line) + segment.Padding)
+	return parser.Continue | parser.NoChildren
+}
+
+// Close implements parser.BlockParser
+func (b *mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+// CanInterruptParagraph implements parser.BlockParser
+func (b *mathBlockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+// CanAcceptIndentedLine implements parser.BlockParser
+func (b *mathBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// mathRenderer renders formulas as MathML (see texToMathML)
+type mathRenderer struct{}
+
+// RegisterFuncs implements renderer.NodeRenderer
+func (r *mathRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMathInline, r.renderMathInline)
+	reg.Register(kindMathBlock, r.renderMathBlock)
+}
+
+// renderMathInline writes an inline formula
+func (r *mathRenderer) renderMathInline(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*mathInline)
+	segments := text.NewSegments()
+	segments.Append(n.Segment)
+	mathml, err := convertMath(source, segments, n.Display)
+	if err != nil {
+		return ast.WalkStop, err
+	}
+	_, _ = w.WriteString(mathml)
+	return ast.WalkSkipChildren, nil
+}
+
+// renderMathBlock writes a display formula in a scrollable container
+func (r *mathRenderer) renderMathBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	if n := node.(*mathBlock); !n.closed {
+		line := bytes.Count(source[:n.open], []byte("\n")) + 1
+		return ast.WalkStop, fmt.Errorf("line %d: unclosed $$ math block", line)
+	}
+	mathml, err := convertMath(source, node.Lines(), true)
+	if err != nil {
+		return ast.WalkStop, err
+	}
+	_, _ = w.WriteString(`<div class="math">` + mathml + "</div>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+// convertMath converts the TeX in segments to MathML. Errors carry the line of
+// the markdown source they occurred on.
+func convertMath(source []byte, segments *text.Segments, display bool) (string, error) {
+	var tex bytes.Buffer
+	for i := 0; i < segments.Len(); i++ {
+		seg := segments.At(i)
+		tex.Write(seg.Value(source))
+	}
+	mathml, err := texToMathML(string(bytes.TrimRight(tex.Bytes(), " \t\r\n")), display)
+
+	var mathErr *mathError
+	if errors.As(err, &mathErr) {
+		// Map the offset in the TeX back to the source
+		offset := mathErr.offset
+		pos := 0
+		for i := 0; i < segments.Len(); i++ {
+			seg := segments.At(i)
+			pos = seg.Start + offset
+			if offset < seg.Len() || i == segments.Len()-1 {
+				break
+			}
+			offset -= seg.Len()
+		}
+		line := bytes.Count(source[:min(pos, len(source))], []byte("\n")) + 1
+		return "", fmt.Errorf("line %d: %s in math %q", line, mathErr.msg, bytes.TrimSpace(tex.Bytes()))
+	}
+	return mathml, err
+}
+
+// mathExtension is a goldmark extension for $...$ and $$...$$ formulas rendered as MathML
+type mathExtension struct{}
+
+// Extend implements goldmark.Extender
+func (e *mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(&mathBlockParser{}, 150)),
+		parser.WithInlineParsers(util.Prioritized(&mathInlineParser{}, 150)),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&mathRenderer{}, 100),
+	))
+}
@@ -0,0 +1,158 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTexToMathML(t *testing.T) {
+	tests := []struct {
+		tex  string
+		want string
+	}{
+		{`\frac{a}{b}`, `<mfrac><mrow><mi>a</mi></mrow><mrow><mi>b</mi></mrow></mfrac>`},
+		{`\sqrt[3]{x}`, `<mroot><mrow><mi>x</mi></mrow><mrow><mn>3</mn></mrow></mroot>`},
+		{`\alpha + \beta`, `<mi>α</mi><mo>+</mo><mi>β</mi>`},
+		{`x_1^2`, `<msubsup><mi>x</mi><mn>1</mn><mn>2</mn></msubsup>`},
+		{`\sum_{i=1}^n i`, `<munderover><mo movablelimits="true">∑</mo>`},
+		{`\sin x`, `<mi>sin</mi><mo>&#x2061;</mo><mi>x</mi>`},
+		{`\operatorname{rank} A`, `<mi mathvariant="normal">rank</mi><mo>&#x2061;</mo>`},
+		{`\mathbb{R}`, `<mi>ℝ</mi>`},
+		{`\hat{x}`, `<mover accent="true"><mrow><mi>x</mi></mrow><mo stretchy="false">^</mo></mover>`},
+		{`\text{if } x`, `<mtext>if </mtext><mi>x</mi>`},
+		{`\binom{n}{k}`, `<mfrac linethickness="0">`},
+		{`\le`, `<mo>≤</mo>`},
+		{`\left( x \right)`, `<mo fence="true" stretchy="true">(</mo><mi>x</mi><mo fence="true" stretchy="true">)</mo>`},
+		{`\begin{pmatrix} a & b \\ c & d \end{pmatrix}`, `<mtr><mtd><mi>a</mi></mtd><mtd><mi>b</mi></mtd></mtr><mtr><mtd><mi>c</mi></mtd><mtd><mi>d</mi></mtd></mtr>`},
+		{`\begin{cases} 1 & x > 0 \\ 0 & \text{otherwise} \end{cases}`, `<mtable columnalign="left left">`},
+		{`a < b`, `<mi>a</mi><mo>&lt;</mo><mi>b</mi>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tex, func(t *testing.T) {
+			got, err := texToMathML(tt.tex, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("missing %q in\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMath(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		want    []string
+		notWant []string
+		wantErr string
+	}{
+		{
+			name:   "inline",
+			source: "解は $x^2$ です\n",
+			want:   []string{"<p>解は <math><semantics><mrow><msup><mi>x</mi><mn>2</mn></msup></mrow>"},
+		},
+		{
+			name:   "block",
+			source: "text\n\n$$\n\\frac{1}{2}\n$$\n",
+			want:   []string{`<div class="math"><math display="block">`, "<mfrac>"},
+		},
+		{
+			name:   "single-line block",
+			source: "$$x$$\n",
+			want:   []string{`<div class="math"><math display="block"><semantics><mrow><mi>x</mi></mrow>`},
+		},
+		{
+			name:    "prices",
+			source:  "$5 and $10\n",
+			want:    []string{"<p>$5 and $10</p>"},
+			notWant: []string{"<math"},
+		},
+		{
+			name:    "price then a closing $",
+			source:  "$5 per month, and$\n",
+			want:    []string{"<p>$5 per month, and$</p>"},
+			notWant: []string{"<math"},
+		},
+		{
+			name:    "closing $ after a space",
+			source:  "$x $ and\n",
+			notWant: []string{"<math"},
+		},
+		{
+			name:    "closing $ before a digit",
+			source:  "$x$5\n",
+			notWant: []string{"<math"},
+		},
+		{
+			name:   "escaped dollar",
+			source: "$\\$5$\n",
+			want:   []string{"<mo>$</mo><mn>5</mn>"},
+		},
+		{
+			name:   "number without a space",
+			source: "$1+1$\n",
+			want:   []string{"<mn>1</mn><mo>+</mo><mn>1</mn>"},
+		},
+		{
+			name:   "inline in a blockquote",
+			source: "> $x^2$\n",
+			want:   []string{"<blockquote>\n<p><math><semantics><mrow><msup>"},
+		},
+		{
+			name:   "block in a blockquote",
+			source: "> $$\n> x\n> $$\n",
+			want:   []string{"<blockquote>\n<div class=\"math\"><math display=\"block\"><semantics><mrow><mi>x</mi></mrow>"},
+		},
+		{
+			name:   "footnote",
+			source: "本文[^1]\n\n[^1]: $y$\n",
+			want:   []string{`<li id="fn-1">` + "\n<p><math><semantics><mrow><mi>y</mi></mrow>"},
+		},
+		{
+			name:    "unsupported command",
+			source:  "intro\n\ntext $\\foo$\n",
+			wantErr: `line 3: unsupported command \foo`,
+		},
+		{
+			name:    "unsupported command in a block",
+			source:  "$$\na\n\\foo\n$$\n",
+			wantErr: `line 3: unsupported command \foo`,
+		},
+		{
+			name:    "unclosed block",
+			source:  "intro\n\n$$\nunterminated\n",
+			wantErr: "line 3: unclosed $$ math block",
+		},
+		{
+			name:    "unclosed block before a heading",
+			source:  "$$\nx\n\n## 見出し\n",
+			wantErr: "line 1: unclosed $$ math block",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article, err := NewParser().Parse([]byte(tt.source), "test")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(article.Content, want) {
+					t.Errorf("missing %q in\n%s", want, article.Content)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(article.Content, notWant) {
+					t.Errorf("unexpected %q in\n%s", notWant, article.Content)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,992 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// mathError is a TeX conversion error at a byte offset of the TeX source
+type mathError struct {
+	offset int
+	msg    string
+}
+
+func (e *mathError) Error() string {
+	return e.msg
+}
+
+// texToMathML converts a practical subset of TeX math into a MathML <math> element.
+// Unsupported commands and environments are errors.
+func texToMathML(tex string, display bool) (string, error) {
+	p := &texParser{src: tex}
+	body, err := p.parseSeq()
+	if err != nil {
+		return "", err
+	}
+	if p.pos < len(p.src) {
+		// parseSeq only stops early at a closing token
+		if p.src[p.pos] == '\\' && p.peekCommand() == "\\" {
+			return "", p.errorf(`\\ is only supported inside environments such as \begin{aligned}`)
+		}
+		return "", p.errorf("unexpected %s", p.src[p.pos:p.pos+p.tokenLen()])
+	}
+
+	var b strings.Builder
+	b.WriteString("<math")
+	if display {
+		b.WriteString(` display="block"`)
+	}
+	b.WriteString("><semantics><mrow>")
+	b.WriteString(body)
+	b.WriteString(`</mrow><annotation encoding="application/x-tex">`)
+	b.WriteString(escapeHTML(tex))
+	b.WriteString("</annotation></semantics></math>")
+	return b.String(), nil
+}
+
+// texParser is a recursive descent parser from TeX math to MathML
+type texParser struct {
+	src string
+	pos int
+	// variant maps letters and digits to a Unicode math alphabet (\mathbf etc.)
+	variant string
+}
+
+// errorf returns a mathError at the current position
+func (p *texParser) errorf(format string, args ...any) error {
+	return &mathError{offset: p.pos, msg: fmt.Sprintf(format, args...)}
+}
+
+// tokenLen returns the length of the token at the current position
+func (p *texParser) tokenLen() int {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	if p.src[p.pos] == '\\' {
+		return len(p.peekCommand()) + 1
+	}
+	_, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	return size
+}
+
+// skipSpace skips whitespace
+func (p *texParser) skipSpace() {
+	for p.pos < len(p.src) && isTeXSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+// isTeXSpace reports whether c is whitespace in math mode
+func isTeXSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// peekCommand returns the name of the command at the current position (after the backslash)
+func (p *texParser) peekCommand() string {
+	i := p.pos + 1
+	if i >= len(p.src) {
+		return ""
+	}
+	if !isASCIILetter(p.src[i]) {
+		return p.src[i : i+1]
+	}
+	j := i
+	for j < len(p.src) && isASCIILetter(p.src[j]) {
+		j++
+	}
+	// Starred environments such as align* are handled by \begin
+	return p.src[i:j]
+}
+
+// isASCIILetter reports whether c is an ASCII letter
+func isASCIILetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// atEnd reports whether the current token ends the sequence being parsed:
+// the end of input, "}", "&", "\\", \end, \right or \middle
+func (p *texParser) atEnd() bool {
+	if p.pos >= len(p.src) {
+		return true
+	}
+	switch p.src[p.pos] {
+	case '}', '&':
+		return true
+	case '\\':
+		switch p.peekCommand() {
+		case "\\", "end", "right", "middle":
+			return true
+		}
+	}
+	return false
+}
+
+// parseSeq parses atoms with their scripts until the end of the sequence (see atEnd)
+func (p *texParser) parseSeq() (string, error) {
+	var b strings.Builder
+	for {
+		p.skipSpace()
+		if p.atEnd() {
+			return b.String(), nil
+		}
+
+		// Style switches apply to the rest of the sequence
+		if p.src[p.pos] == '\\' {
+			if style, ok := styleCommands[p.peekCommand()]; ok {
+				p.pos += len(p.peekCommand()) + 1
+				rest, err := p.parseSeq()
+				if err != nil {
+					return "", err
+				}
+				b.WriteString(`<mstyle displaystyle="` + style + `">` + rest + "</mstyle>")
+				continue
+			}
+		}
+
+		a, err := p.parseAtom()
+		if err != nil {
+			return "", err
+		}
+		s, err := p.parseScripts(a)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(s)
+	}
+}
+
+// atom is a parsed element that scripts attach to
+type atom struct {
+	mathml string
+	// limits places scripts above and below (\sum, \lim, ...)
+	limits bool
+	// after follows the scripts, like the function application after \sin
+	after string
+}
+
+// parseScripts parses the ^ and _ scripts following base
+func (p *texParser) parseScripts(base atom) (string, error) {
+	var sub, sup string
+	var hasSub, hasSup bool
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			break
+		}
+		c := p.src[p.pos]
+		if c == '\\' {
+			switch p.peekCommand() {
+			case "limits":
+				p.pos += len("limits") + 1
+				continue
+			case "nolimits":
+				p.pos += len("nolimits") + 1
+				base.limits = false
+				continue
+			}
+		}
+		if c != '^' && c != '_' {
+			break
+		}
+		if (c == '^' && hasSup) || (c == '_' && hasSub) {
+			return "", p.errorf("double %s", string(c))
+		}
+		p.pos++
+		arg, err := p.parseArg()
+		if err != nil {
+			return "", err
+		}
+		if c == '^' {
+			sup, hasSup = arg, true
+		} else {
+			sub, hasSub = arg, true
+		}
+	}
+
+	under, over, both := "msub", "msup", "msubsup"
+	if base.limits {
+		under, over, both = "munder", "mover", "munderover"
+	}
+	switch {
+	case hasSub && hasSup:
+		return "<" + both + ">" + base.mathml + sub + sup + "</" + both + ">" + base.after, nil
+	case hasSub:
+		return "<" + under + ">" + base.mathml + sub + "</" + under + ">" + base.after, nil
+	case hasSup:
+		return "<" + over + ">" + base.mathml + sup + "</" + over + ">" + base.after, nil
+	}
+	return base.mathml + base.after, nil
+}
+
+// parseArg parses a command argument or script: a {group} or a single token
+func (p *texParser) parseArg() (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return "", p.errorf("missing argument")
+	}
+	switch p.src[p.pos] {
+	case '{':
+		return p.parseGroup()
+	case '}', '&', '^', '_':
+		return "", p.errorf("missing argument")
+	}
+	if isDigit(p.src[p.pos]) {
+		// A single digit, unlike a number outside arguments
+		p.pos++
+		return "<mn>" + p.mapVariant(p.src[p.pos-1:p.pos]) + "</mn>", nil
+	}
+	a, err := p.parseAtom()
+	return a.mathml + a.after, err
+}
+
+// parseGroup parses {...} into an mrow
+func (p *texParser) parseGroup() (string, error) {
+	start := p.pos
+	p.pos++ // {
+	body, err := p.parseSeq()
+	if err != nil {
+		return "", err
+	}
+	if p.pos >= len(p.src) || p.src[p.pos] != '}' {
+		p.pos = start
+		return "", p.errorf("unclosed {")
+	}
+	p.pos++
+	return "<mrow>" + body + "</mrow>", nil
+}
+
+// parseOptional parses an optional [...] argument, returning false if there is none
+func (p *texParser) parseOptional() (string, bool, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) || p.src[p.pos] != '[' {
+		return "", false, nil
+	}
+	start := p.pos
+	p.pos++
+	var b strings.Builder
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			p.pos = start
+			return "", false, p.errorf("unclosed [")
+		}
+		if p.src[p.pos] == ']' {
+			p.pos++
+			return "<mrow>" + b.String() + "</mrow>", true, nil
+		}
+		if p.atEnd() {
+			return "", false, p.errorf("unexpected %s", p.src[p.pos:p.pos+p.tokenLen()])
+		}
+		a, err := p.parseAtom()
+		if err != nil {
+			return "", false, err
+		}
+		s, err := p.parseScripts(a)
+		if err != nil {
+			return "", false, err
+		}
+		b.WriteString(s)
+	}
+}
+
+// parseTextArg parses a {...} argument as raw text
+func (p *texParser) parseTextArg() (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) || p.src[p.pos] != '{' {
+		return "", p.errorf("missing argument")
+	}
+	start := p.pos
+	depth := 0
+	for i := p.pos; i < len(p.src); i++ {
+		switch p.src[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				p.pos = i + 1
+				return p.src[start+1 : i], nil
+			}
+		}
+	}
+	return "", p.errorf("unclosed {")
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// parseAtom parses one element: a group, number, letter, symbol or command
+func (p *texParser) parseAtom() (atom, error) {
+	c := p.src[p.pos]
+	switch {
+	case c == '{':
+		s, err := p.parseGroup()
+		return atom{mathml: s}, err
+	case c == '\\':
+		return p.parseCommand()
+	case isDigit(c) || c == '.' && p.pos+1 < len(p.src) && isDigit(p.src[p.pos+1]):
+		start := p.pos
+		for p.pos < len(p.src) && (isDigit(p.src[p.pos]) ||
+			p.src[p.pos] == '.' && p.pos+1 < len(p.src) && isDigit(p.src[p.pos+1])) {
+			p.pos++
+		}
+		return atom{mathml: "<mn>" + p.mapVariant(p.src[start:p.pos]) + "</mn>"}, nil
+	case isASCIILetter(c):
+		p.pos++
+		return atom{mathml: p.identifier(string(c))}, nil
+	case c == '^' || c == '_':
+		// Scripts without a base
+		return atom{mathml: "<mrow></mrow>"}, nil
+	case c == '~':
+		p.pos++
+		return atom{mathml: "<mtext>&#160;</mtext>"}, nil
+	case c == '\'':
+		p.pos++
+		return atom{mathml: "<mo>′</mo>"}, nil
+	case c == '-':
+		p.pos++
+		return atom{mathml: "<mo>−</mo>"}, nil
+	case c == '#' || c == '$' || c == '%':
+		return atom{}, p.errorf("unsupported character %s", string(c))
+	case strings.IndexByte("()[]|", c) >= 0:
+		p.pos++
+		return atom{mathml: `<mo stretchy="false">` + string(c) + "</mo>"}, nil
+	case c < utf8.RuneSelf:
+		p.pos++
+		return atom{mathml: "<mo>" + escapeHTML(string(c)) + "</mo>"}, nil
+	}
+
+	r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	p.pos += size
+	if unicode.IsLetter(r) {
+		return atom{mathml: "<mi>" + string(r) + "</mi>"}, nil
+	}
+	return atom{mathml: "<mo>" + escapeHTML(string(r)) + "</mo>"}, nil
+}
+
+// identifier returns the mi of a letter in the current variant
+func (p *texParser) identifier(s string) string {
+	switch p.variant {
+	case "":
+		return "<mi>" + s + "</mi>"
+	case "normal":
+		return `<mi mathvariant="normal">` + s + "</mi>"
+	}
+	return "<mi>" + p.mapVariant(s) + "</mi>"
+}
+
+// parseCommand parses a backslash command
+func (p *texParser) parseCommand() (atom, error) {
+	start := p.pos
+	name := p.peekCommand()
+	if name == "" {
+		return atom{}, p.errorf("incomplete command")
+	}
+	p.pos += len(name) + 1
+
+	if sym, ok := texSymbols[name]; ok {
+		return atom{mathml: sym.mathml(), limits: sym.limits}, nil
+	}
+	if mathml, ok := texFunctions[name]; ok {
+		return atom{mathml: mathml, after: "<mo>&#x2061;</mo>"}, nil
+	}
+	if mathml, ok := texLimitFunctions[name]; ok {
+		return atom{mathml: mathml, limits: true}, nil
+	}
+	if width, ok := texSpaces[name]; ok {
+		return atom{mathml: `<mspace width="` + width + `"></mspace>`}, nil
+	}
+	if variant, ok := texVariants[name]; ok {
+		saved := p.variant
+		p.variant = variant
+		arg, err := p.parseArg()
+		p.variant = saved
+		return atom{mathml: arg}, err
+	}
+	if accent, ok := texAccents[name]; ok {
+		arg, err := p.parseArg()
+		if err != nil {
+			return atom{}, err
+		}
+		if accent.under {
+			return atom{mathml: `<munder accentunder="true">` + arg + `<mo stretchy="true">` + accent.char + "</mo></munder>"}, nil
+		}
+		return atom{mathml: `<mover accent="true">` + arg + "<mo" + accent.stretchy() + ">" + accent.char + "</mo></mover>"}, nil
+	}
+	if size, ok := texBigSizes[strings.TrimRight(name, "lrm")]; ok {
+		delim, err := p.parseDelimiter()
+		if err != nil {
+			return atom{}, err
+		}
+		return atom{mathml: `<mo stretchy="true" symmetric="true" minsize="` + size + `" maxsize="` + size + `">` + delim + "</mo>"}, nil
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac", "binom":
+		num, err := p.parseArg()
+		if err != nil {
+			return atom{}, err
+		}
+		den, err := p.parseArg()
+		if err != nil {
+			return atom{}, err
+		}
+		if name == "binom" {
+			return atom{mathml: `<mrow><mo>(</mo><mfrac linethickness="0">` + num + den + "</mfrac><mo>)</mo></mrow>"}, nil
+		}
+		frac := "<mfrac>" + num + den + "</mfrac>"
+		switch name {
+		case "dfrac":
+			frac = `<mstyle displaystyle="true">` + frac + "</mstyle>"
+		case "tfrac":
+			frac = `<mstyle displaystyle="false">` + frac + "</mstyle>"
+		}
+		return atom{mathml: frac}, nil
+	case "sqrt":
+		index, ok, err := p.parseOptional()
+		if err != nil {
+			return atom{}, err
+		}
+		arg, err := p.parseArg()
+		if err != nil {
+			return atom{}, err
+		}
+		if ok {
+			return atom{mathml: "<mroot>" + arg + index + "</mroot>"}, nil
+		}
+		return atom{mathml: "<msqrt>" + arg + "</msqrt>"}, nil
+	case "overset", "stackrel", "underset":
+		script, err := p.parseArg()
+		if err != nil {
+			return atom{}, err
+		}
+		base, err := p.parseArg()
+		if err != nil {
+			return atom{}, err
+		}
+		if name == "underset" {
+			return atom{mathml: "<munder>" + base + script + "</munder>"}, nil
+		}
+		return atom{mathml: "<mover>" + base + script + "</mover>"}, nil
+	case "text", "textrm", "mbox", "textit", "textbf":
+		text, err := p.parseTextArg()
+		if err != nil {
+			return atom{}, err
+		}
+		attr := ""
+		switch name {
+		case "textit":
+			attr = ` mathvariant="italic"`
+		case "textbf":
+			attr = ` mathvariant="bold"`
+		}
+		return atom{mathml: "<mtext" + attr + ">" + escapeHTML(unescapeTeXText(text)) + "</mtext>"}, nil
+	case "operatorname":
+		text, err := p.parseTextArg()
+		if err != nil {
+			return atom{}, err
+		}
+		return atom{mathml: `<mi mathvariant="normal">` + escapeHTML(text) + "</mi>", after: "<mo>&#x2061;</mo>"}, nil
+	case "not":
+		p.skipSpace()
+		if p.pos >= len(p.src) || p.atEnd() {
+			return atom{}, p.errorf("missing argument")
+		}
+		next, err := p.parseAtom()
+		if err != nil {
+			return atom{}, err
+		}
+		// Overlay a long solidus on the operator
+		if i := strings.Index(next.mathml, "</mo>"); i >= 0 {
+			return atom{mathml: next.mathml[:i] + "\u0338" + next.mathml[i:], after: next.after}, nil
+		}
+		return atom{mathml: "<mo>\u0338</mo>" + next.mathml + next.after}, nil
+	case "pmod":
+		arg, err := p.parseArg()
+		if err != nil {
+			return atom{}, err
+		}
+		return atom{mathml: `<mspace width="1em"></mspace><mo stretchy="false">(</mo><mi>mod</mi><mspace width="0.3333em"></mspace>` + arg + `<mo stretchy="false">)</mo>`}, nil
+	case "left":
+		return p.parseLeftRight(start)
+	case "begin":
+		return p.parseEnvironment(start)
+	case "\\", "right", "middle", "end":
+		p.pos = start
+		return atom{}, p.errorf(`unexpected \%s`, name)
+	}
+
+	p.pos = start
+	return atom{}, p.errorf(`unsupported command \%s`, name)
+}
+
+// parseDelimiter parses the delimiter following \left, \right, \middle or \big; "." is none
+func (p *texParser) parseDelimiter() (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return "", p.errorf("missing delimiter")
+	}
+	c := p.src[p.pos]
+	if c == '\\' {
+		name := p.peekCommand()
+		if delim, ok := texDelimiters[name]; ok {
+			p.pos += len(name) + 1
+			return delim, nil
+		}
+		return "", p.errorf(`unsupported delimiter \%s`, name)
+	}
+	if strings.IndexByte("()[]|/.<>", c) < 0 {
+		return "", p.errorf("unsupported delimiter %s", p.src[p.pos:p.pos+p.tokenLen()])
+	}
+	p.pos++
+	switch c {
+	case '.':
+		return "", nil
+	case '<':
+		return "⟨", nil
+	case '>':
+		return "⟩", nil
+	}
+	return string(c), nil
+}
+
+// parseLeftRight parses \left( ... \middle| ... \right) after \left
+func (p *texParser) parseLeftRight(start int) (atom, error) {
+	var b strings.Builder
+	b.WriteString("<mrow>")
+	delim, err := p.parseDelimiter()
+	if err != nil {
+		return atom{}, err
+	}
+	b.WriteString(fenceMo(delim))
+	for {
+		body, err := p.parseSeq()
+		if err != nil {
+			return atom{}, err
+		}
+		b.WriteString(body)
+
+		name := ""
+		if p.pos < len(p.src) && p.src[p.pos] == '\\' {
+			name = p.peekCommand()
+		}
+		switch name {
+		case "middle", "right":
+			p.pos += len(name) + 1
+			delim, err := p.parseDelimiter()
+			if err != nil {
+				return atom{}, err
+			}
+			b.WriteString(fenceMo(delim))
+			if name == "right" {
+				b.WriteString("</mrow>")
+				return atom{mathml: b.String()}, nil
+			}
+		default:
+			p.pos = start
+			return atom{}, p.errorf(`\left without \right`)
+		}
+	}
+}
+
+// fenceMo returns a stretchy delimiter, or an empty mrow for "."
+func fenceMo(delim string) string {
+	if delim == "" {
+		return "<mrow></mrow>"
+	}
+	return `<mo fence="true" stretchy="true">` + escapeHTML(delim) + "</mo>"
+}
+
+// texEnvironment describes the delimiters and column alignment of an environment's table
+type texEnvironment struct {
+	open, close string
+	align       string
+}
+
+// texEnvironments are the supported \begin{...} environments
+var texEnvironments = map[string]texEnvironment{
+	"matrix":   {},
+	"pmatrix":  {open: "(", close: ")"},
+	"bmatrix":  {open: "[", close: "]"},
+	"Bmatrix":  {open: "{", close: "}"},
+	"vmatrix":  {open: "|", close: "|"},
+	"Vmatrix":  {open: "‖", close: "‖"},
+	"cases":    {open: "{", align: "left left"},
+	"aligned":  {align: "right left right left"},
+	"align":    {align: "right left right left"},
+	"align*":   {align: "right left right left"},
+	"gathered": {},
+	"array":    {},
+}
+
+// parseEnvironment parses \begin{env} rows separated by \\ and cells by & up to \end{env}
+func (p *texParser) parseEnvironment(start int) (atom, error) {
+	name, err := p.parseTextArg()
+	if err != nil {
+		return atom{}, err
+	}
+	env, ok := texEnvironments[name]
+	if !ok {
+		p.pos = start
+		return atom{}, p.errorf("unsupported environment %s", name)
+	}
+	if name == "array" {
+		// Column specification, which is not used
+		if _, err := p.parseTextArg(); err != nil {
+			return atom{}, err
+		}
+	}
+
+	var rows [][]string
+	row := []string{}
+	for {
+		cell, err := p.parseSeq()
+		if err != nil {
+			return atom{}, err
+		}
+		row = append(row, cell)
+
+		if p.pos >= len(p.src) {
+			p.pos = start
+			return atom{}, p.errorf(`\begin{%s} without \end{%s}`, name, name)
+		}
+		if p.src[p.pos] == '&' {
+			p.pos++
+			continue
+		}
+		if p.src[p.pos] == '}' {
+			return atom{}, p.errorf("unexpected }")
+		}
+		switch cmd := p.peekCommand(); cmd {
+		case "\\":
+			p.pos += 2
+			// Optional row spacing such as \\[2pt]
+			p.skipSpace()
+			if p.pos < len(p.src) && p.src[p.pos] == '[' {
+				if i := strings.IndexByte(p.src[p.pos:], ']'); i >= 0 {
+					p.pos += i + 1
+				}
+			}
+			rows = append(rows, row)
+			row = []string{}
+			continue
+		case "end":
+			p.pos += len(cmd) + 1
+			end, err := p.parseTextArg()
+			if err != nil {
+				return atom{}, err
+			}
+			if end != name {
+				return atom{}, p.errorf(`\begin{%s} ended by \end{%s}`, name, end)
+			}
+		default:
+			return atom{}, p.errorf(`unexpected \%s`, cmd)
+		}
+		break
+	}
+	// A trailing \\ leaves an empty last row
+	if len(row) > 1 || row[0] != "" {
+		rows = append(rows, row)
+	}
+
+	var b strings.Builder
+	b.WriteString("<mrow>")
+	if env.open != "" {
+		b.WriteString(fenceMo(env.open))
+	}
+	if env.align != "" {
+		b.WriteString(`<mtable columnalign="` + env.align + `">`)
+	} else {
+		b.WriteString("<mtable>")
+	}
+	for _, cells := range rows {
+		b.WriteString("<mtr>")
+		for _, cell := range cells {
+			b.WriteString("<mtd>" + cell + "</mtd>")
+		}
+		b.WriteString("</mtr>")
+	}
+	b.WriteString("</mtable>")
+	if env.close != "" {
+		b.WriteString(fenceMo(env.close))
+	}
+	b.WriteString("</mrow>")
+	return atom{mathml: b.String()}, nil
+}
+
+// unescapeTeXText removes the backslash of escaped characters in \text
+func unescapeTeXText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`{}$%&#_\ `, s[i+1]) >= 0 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// mapVariant maps the letters and digits of s to the current Unicode math alphabet
+func (p *texParser) mapVariant(s string) string {
+	alphabet, ok := mathAlphabets[p.variant]
+	if !ok {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		b.WriteRune(alphabet.mapRune(r))
+	}
+	return b.String()
+}
+
+// mathAlphabet is a Unicode Mathematical Alphanumeric Symbols alphabet
+type mathAlphabet struct {
+	upper, lower, digit rune // first code point of A, a and 0 (0 if absent)
+	// exceptions are letters encoded outside the block (e.g. ℝ)
+	exceptions map[rune]rune
+}
+
+// mapRune maps an ASCII letter or digit into the alphabet
+func (a mathAlphabet) mapRune(r rune) rune {
+	if mapped, ok := a.exceptions[r]; ok {
+		return mapped
+	}
+	switch {
+	case 'A' <= r && r <= 'Z' && a.upper != 0:
+		return a.upper + r - 'A'
+	case 'a' <= r && r <= 'z' && a.lower != 0:
+		return a.lower + r - 'a'
+	case '0' <= r && r <= '9' && a.digit != 0:
+		return a.digit + r - '0'
+	}
+	return r
+}
+
+// mathAlphabets are the alphabets of the font commands (see texVariants)
+var mathAlphabets = map[string]mathAlphabet{
+	"bold":        {upper: 0x1D400, lower: 0x1D41A, digit: 0x1D7CE},
+	"bold-italic": {upper: 0x1D468, lower: 0x1D482, digit: 0x1D7CE},
+	"double-struck": {upper: 0x1D538, lower: 0x1D552, digit: 0x1D7D8, exceptions: map[rune]rune{
+		'C': 'ℂ', 'H': 'ℍ', 'N': 'ℕ', 'P': 'ℙ', 'Q': 'ℚ', 'R': 'ℝ', 'Z': 'ℤ',
+	}},
+	"script": {upper: 0x1D49C, lower: 0x1D4B6, exceptions: map[rune]rune{
+		'B': 'ℬ', 'E': 'ℰ', 'F': 'ℱ', 'H': 'ℋ', 'I': 'ℐ', 'L': 'ℒ', 'M': 'ℳ', 'R': 'ℛ',
+		'e': 'ℯ', 'g': 'ℊ', 'o': 'ℴ',
+	}},
+	"fraktur": {upper: 0x1D504, lower: 0x1D51E, exceptions: map[rune]rune{
+		'C': 'ℭ', 'H': 'ℌ', 'I': 'ℑ', 'R': 'ℜ', 'Z': 'ℨ',
+	}},
+	"sans-serif": {upper: 0x1D5A0, lower: 0x1D5BA, digit: 0x1D7E2},
+	"monospace":  {upper: 0x1D670, lower: 0x1D68A, digit: 0x1D7F6},
+}
+
+// texVariants maps font commands to alphabets; "normal" keeps letters upright
+var texVariants = map[string]string{
+	"mathrm":     "normal",
+	"mathit":     "",
+	"mathbf":     "bold",
+	"boldsymbol": "bold-italic",
+	"bm":         "bold-italic",
+	"mathbb":     "double-struck",
+	"mathcal":    "script",
+	"mathscr":    "script",
+	"mathfrak":   "fraktur",
+	"mathsf":     "sans-serif",
+	"mathtt":     "monospace",
+}
+
+// styleCommands switch between display and inline style for the rest of the group
+var styleCommands = map[string]string{
+	"displaystyle": "true",
+	"textstyle":    "false",
+}
+
+// texSymbol is a command that stands for a single character
+type texSymbol struct {
+	char string
+	// op makes the character an operator (mo) rather than an identifier (mi)
+	op bool
+	// upright keeps an identifier from being italicized (uppercase Greek)
+	upright bool
+	// limits places scripts above and below (large operators)
+	limits bool
+	// fixed keeps a delimiter from stretching
+	fixed bool
+}
+
+// mathml returns the element of the symbol
+func (s texSymbol) mathml() string {
+	char := escapeHTML(s.char)
+	switch {
+	case s.limits:
+		return `<mo movablelimits="true">` + char + "</mo>"
+	case s.fixed:
+		return `<mo stretchy="false">` + char + "</mo>"
+	case s.op:
+		return "<mo>" + char + "</mo>"
+	case s.upright:
+		return `<mi mathvariant="normal">` + char + "</mi>"
+	}
+	return "<mi>" + char + "</mi>"
+}
+
+// texSymbols are the supported symbol commands
+var texSymbols = map[string]texSymbol{
+	// Greek letters
+	"alpha": {char: "α"}, "beta": {char: "β"}, "gamma": {char: "γ"}, "delta": {char: "δ"},
+	"epsilon": {char: "ϵ"}, "varepsilon": {char: "ε"}, "zeta": {char: "ζ"}, "eta": {char: "η"},
+	"theta": {char: "θ"}, "vartheta": {char: "ϑ"}, "iota": {char: "ι"}, "kappa": {char: "κ"},
+	"lambda": {char: "λ"}, "mu": {char: "μ"}, "nu": {char: "ν"}, "xi": {char: "ξ"},
+	"omicron": {char: "ο"}, "pi": {char: "π"}, "varpi": {char: "ϖ"}, "rho": {char: "ρ"},
+	"varrho": {char: "ϱ"}, "sigma": {char: "σ"}, "varsigma": {char: "ς"}, "tau": {char: "τ"},
+	"upsilon": {char: "υ"}, "phi": {char: "ϕ"}, "varphi": {char: "φ"}, "chi": {char: "χ"},
+	"psi": {char: "ψ"}, "omega": {char: "ω"},
+	"Gamma": {char: "Γ", upright: true}, "Delta": {char: "Δ", upright: true}, "Theta": {char: "Θ", upright: true},
+	"Lambda": {char: "Λ", upright: true}, "Xi": {char: "Ξ", upright: true}, "Pi": {char: "Π", upright: true},
+	"Sigma": {char: "Σ", upright: true}, "Upsilon": {char: "Υ", upright: true}, "Phi": {char: "Φ", upright: true},
+	"Psi": {char: "Ψ", upright: true}, "Omega": {char: "Ω", upright: true},
+
+	// Letter-like symbols
+	"infty": {char: "∞", upright: true}, "partial": {char: "∂", upright: true}, "nabla": {char: "∇", upright: true},
+	"emptyset": {char: "∅", upright: true}, "varnothing": {char: "∅", upright: true}, "hbar": {char: "ℏ"},
+	"ell": {char: "ℓ"}, "aleph": {char: "ℵ", upright: true}, "Re": {char: "ℜ", upright: true},
+	"Im": {char: "ℑ", upright: true}, "wp": {char: "℘"}, "angle": {char: "∠", upright: true},
+	"triangle": {char: "△", upright: true}, "square": {char: "□", upright: true}, "top": {char: "⊤", upright: true},
+	"bot": {char: "⊥", upright: true}, "checkmark": {char: "✓", upright: true}, "imath": {char: "ı"}, "jmath": {char: "ȷ"},
+
+	// Binary operators
+	"pm": {char: "±", op: true}, "mp": {char: "∓", op: true}, "times": {char: "×", op: true},
+	"div": {char: "÷", op: true}, "cdot": {char: "⋅", op: true}, "ast": {char: "∗", op: true},
+	"star": {char: "⋆", op: true}, "circ": {char: "∘", op: true}, "bullet": {char: "∙", op: true},
+	"oplus": {char: "⊕", op: true}, "ominus": {char: "⊖", op: true}, "otimes": {char: "⊗", op: true},
+	"odot": {char: "⊙", op: true}, "wedge": {char: "∧", op: true}, "land": {char: "∧", op: true},
+	"vee": {char: "∨", op: true}, "lor": {char: "∨", op: true}, "cap": {char: "∩", op: true},
+	"cup": {char: "∪", op: true}, "setminus": {char: "∖", op: true}, "sqcup": {char: "⊔", op: true},
+	"sqcap": {char: "⊓", op: true}, "uplus": {char: "⊎", op: true}, "dagger": {char: "†", op: true},
+	"ddagger": {char: "‡", op: true}, "bmod": {char: "mod", op: true}, "mod": {char: "mod", op: true},
+
+	// Relations
+	"leq": {char: "≤", op: true}, "le": {char: "≤", op: true}, "geq": {char: "≥", op: true},
+	"ge": {char: "≥", op: true}, "leqq": {char: "≦", op: true}, "geqq": {char: "≧", op: true},
+	"leqslant": {char: "⩽", op: true}, "geqslant": {char: "⩾", op: true}, "lt": {char: "<", op: true},
+	"gt": {char: ">", op: true}, "neq": {char: "≠", op: true}, "ne": {char: "≠", op: true},
+	"ll": {char: "≪", op: true}, "gg": {char: "≫", op: true}, "approx": {char: "≈", op: true},
+	"equiv": {char: "≡", op: true}, "sim": {char: "∼", op: true}, "simeq": {char: "≃", op: true},
+	"cong": {char: "≅", op: true}, "propto": {char: "∝", op: true}, "asymp": {char: "≍", op: true},
+	"doteq": {char: "≐", op: true}, "lesssim": {char: "≲", op: true}, "gtrsim": {char: "≳", op: true},
+	"prec": {char: "≺", op: true}, "succ": {char: "≻", op: true}, "preceq": {char: "⪯", op: true},
+	"succeq": {char: "⪰", op: true}, "in": {char: "∈", op: true}, "notin": {char: "∉", op: true},
+	"ni": {char: "∋", op: true}, "subset": {char: "⊂", op: true}, "supset": {char: "⊃", op: true},
+	"subseteq": {char: "⊆", op: true}, "supseteq": {char: "⊇", op: true}, "subsetneq": {char: "⊊", op: true},
+	"supsetneq": {char: "⊋", op: true}, "sqsubseteq": {char: "⊑", op: true}, "sqsupseteq": {char: "⊒", op: true},
+	"mid": {char: "∣", op: true}, "nmid": {char: "∤", op: true}, "parallel": {char: "∥", op: true},
+	"perp": {char: "⊥", op: true}, "models": {char: "⊨", op: true}, "vdash": {char: "⊢", op: true},
+	"dashv": {char: "⊣", op: true}, "therefore": {char: "∴", op: true}, "because": {char: "∵", op: true},
+
+	// Arrows
+	"to": {char: "→", op: true}, "rightarrow": {char: "→", op: true}, "leftarrow": {char: "←", op: true},
+	"gets": {char: "←", op: true}, "leftrightarrow": {char: "↔", op: true}, "Rightarrow": {char: "⇒", op: true},
+	"Leftarrow": {char: "⇐", op: true}, "Leftrightarrow": {char: "⇔", op: true}, "implies": {char: "⟹", op: true},
+	"impliedby": {char: "⟸", op: true}, "iff": {char: "⟺", op: true}, "mapsto": {char: "↦", op: true},
+	"longrightarrow": {char: "⟶", op: true}, "longleftarrow": {char: "⟵", op: true},
+	"longleftrightarrow": {char: "⟷", op: true}, "Longrightarrow": {char: "⟹", op: true},
+	"Longleftarrow": {char: "⟸", op: true}, "Longleftrightarrow": {char: "⟺", op: true},
+	"longmapsto": {char: "⟼", op: true}, "uparrow": {char: "↑", op: true}, "downarrow": {char: "↓", op: true},
+	"Uparrow": {char: "⇑", op: true}, "Downarrow": {char: "⇓", op: true}, "updownarrow": {char: "↕", op: true},
+	"nearrow": {char: "↗", op: true}, "searrow": {char: "↘", op: true}, "hookrightarrow": {char: "↪", op: true},
+	"hookleftarrow": {char: "↩", op: true}, "rightleftharpoons": {char: "⇌", op: true},
+
+	// Logic and punctuation
+	"forall": {char: "∀", op: true}, "exists": {char: "∃", op: true}, "nexists": {char: "∄", op: true},
+	"neg": {char: "¬", op: true}, "lnot": {char: "¬", op: true}, "prime": {char: "′", op: true},
+	"ldots": {char: "…", op: true}, "dots": {char: "…", op: true}, "cdots": {char: "⋯", op: true},
+	"vdots": {char: "⋮", op: true}, "ddots": {char: "⋱", op: true}, "colon": {char: ":", op: true},
+	"{": {char: "{", fixed: true}, "}": {char: "}", fixed: true}, "|": {char: "‖", fixed: true},
+	"lbrace": {char: "{", fixed: true}, "rbrace": {char: "}", fixed: true}, "langle": {char: "⟨", fixed: true},
+	"rangle": {char: "⟩", fixed: true}, "lfloor": {char: "⌊", fixed: true}, "rfloor": {char: "⌋", fixed: true},
+	"lceil": {char: "⌈", fixed: true}, "rceil": {char: "⌉", fixed: true}, "vert": {char: "|", fixed: true},
+	"Vert": {char: "‖", fixed: true}, "lvert": {char: "|", fixed: true}, "rvert": {char: "|", fixed: true},
+	"lVert": {char: "‖", fixed: true}, "rVert": {char: "‖", fixed: true}, "backslash": {char: "\\", fixed: true},
+	"%": {char: "%", op: true}, "&": {char: "&", op: true}, "#": {char: "#", op: true},
+	"$": {char: "$", op: true}, "_": {char: "_", op: true},
+
+	// Large operators
+	"sum": {char: "∑", limits: true}, "prod": {char: "∏", limits: true}, "coprod": {char: "∐", limits: true},
+	"bigcup": {char: "⋃", limits: true}, "bigcap": {char: "⋂", limits: true}, "bigvee": {char: "⋁", limits: true},
+	"bigwedge": {char: "⋀", limits: true}, "bigoplus": {char: "⨁", limits: true}, "bigotimes": {char: "⨂", limits: true},
+	"bigsqcup": {char: "⨆", limits: true},
+	"int":      {char: "∫", op: true}, "iint": {char: "∬", op: true}, "iiint": {char: "∭", op: true}, "oint": {char: "∮", op: true},
+}
+
+// texFunctions are function names written upright, followed by a function application
+var texFunctions = map[string]string{}
+
+// texLimitFunctions are function names taking scripts above and below, like \lim
+var texLimitFunctions = map[string]string{}
+
+func init() {
+	for _, name := range []string{
+		"sin", "cos", "tan", "cot", "sec", "csc", "arcsin", "arccos", "arctan",
+		"sinh", "cosh", "tanh", "coth", "log", "ln", "lg", "exp", "deg", "dim", "ker", "hom", "arg",
+	} {
+		texFunctions[name] = "<mi>" + name + "</mi>"
+	}
+	for name, text := range map[string]string{
+		"lim": "lim", "limsup": "lim sup", "liminf": "lim inf", "max": "max", "min": "min",
+		"sup": "sup", "inf": "inf", "det": "det", "gcd": "gcd", "Pr": "Pr", "argmax": "arg max", "argmin": "arg min",
+	} {
+		texLimitFunctions[name] = `<mo movablelimits="true" form="prefix">` + text + "</mo>"
+	}
+}
+
+// texSpaces are the spacing commands and their widths
+var texSpaces = map[string]string{
+	",": "0.1667em", "thinspace": "0.1667em", ":": "0.2222em", ">": "0.2222em", "medspace": "0.2222em",
+	";": "0.2778em", "thickspace": "0.2778em", "!": "-0.1667em", "negthinspace": "-0.1667em",
+	" ": "0.25em", "quad": "1em", "qquad": "2em",
+}
+
+// texAccent is an accent placed over or under its argument
+type texAccent struct {
+	char  string
+	under bool
+	// wide stretches the accent over the whole argument
+	wide bool
+}
+
+// stretchy returns the stretchy attribute of the accent
+func (a texAccent) stretchy() string {
+	if a.wide {
+		return ` stretchy="true"`
+	}
+	return ` stretchy="false"`
+}
+
+// texAccents are the supported accent commands
+var texAccents = map[string]texAccent{
+	"hat": {char: "^"}, "widehat": {char: "^", wide: true}, "bar": {char: "¯"},
+	"overline": {char: "¯", wide: true}, "vec": {char: "→"}, "overrightarrow": {char: "→", wide: true},
+	"overleftarrow": {char: "←", wide: true}, "tilde": {char: "~"}, "widetilde": {char: "~", wide: true},
+	"dot": {char: "˙"}, "ddot": {char: "¨"}, "acute": {char: "´"}, "grave": {char: "`"},
+	"check": {char: "ˇ"}, "breve": {char: "˘"}, "underline": {char: "_", under: true},
+	"overbrace": {char: "⏞", wide: true}, "underbrace": {char: "⏟", under: true},
+}
+
+// texBigSizes are the sizes of the \big delimiter commands
+var texBigSizes = map[string]string{
+	"big": "1.2em", "Big": "1.623em", "bigg": "2.047em", "Bigg": "2.470em",
+}
+
+// texDelimiters are the delimiter commands accepted by \left, \right and \big
+var texDelimiters = map[string]string{
+	"{": "{", "}": "}", "|": "‖", "lbrace": "{", "rbrace": "}", "langle": "⟨", "rangle": "⟩",
+	"lfloor": "⌊", "rfloor": "⌋", "lceil": "⌈", "rceil": "⌉", "vert": "|", "Vert": "‖",
+	"lvert": "|", "rvert": "|", "lVert": "‖", "rVert": "‖", "backslash": "\\",
+	"uparrow": "↑", "downarrow": "↓", "Uparrow": "⇑", "Downarrow": "⇓",
+}
@@ -1,8 +1,9 @@
 package markdown
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"net/http"
 	"strings"
 
 	"golang.org/x/net/html"
@@ -10,44 +11,33 @@ import (
 
 // OGData contains Open Graph metadata
 type OGData struct {
-	Title       string
-	Description string
-	Image       string
-	URL         string
-	SiteName    string
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Image       string `json:"image,omitempty"`
+	URL         string `json:"url"`
+	SiteName    string `json:"site_name,omitempty"`
 }
 
 // OGCardFetcher handles fetching OG metadata from URLs
 type OGCardFetcher struct {
-	client *http.Client
+	fetcher *Fetcher
 }
 
-// NewOGCardFetcher creates a new OGCardFetcher
-func NewOGCardFetcher() *OGCardFetcher {
+// NewOGCardFetcher creates a new OGCardFetcher that fetches with fetcher
+func NewOGCardFetcher(fetcher *Fetcher) *OGCardFetcher {
 	return &OGCardFetcher{
-		client: &http.Client{},
+		fetcher: fetcher,
 	}
 }
 
 // FetchOGData fetches Open Graph metadata from a URL
-func (o *OGCardFetcher) FetchOGData(url string) (*OGData, error) {
-	req, err := http.NewRequest("GET", url, nil)
+func (o *OGCardFetcher) FetchOGData(ctx context.Context, url string) (*OGData, error) {
+	body, err := o.fetcher.Get(ctx, url)
 	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; OGCardBot/1.0)")
-
-	resp, err := o.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch URL: status %d", resp.StatusCode)
+		return nil, fmt.Errorf("failed to fetch URL: %w", err)
 	}
 
-	doc, err := html.Parse(resp.Body)
+	doc, err := html.Parse(bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -92,8 +82,8 @@ func extractOGData(n *html.Node, data *OGData) {
 }
 
 // GenerateOGCard generates HTML for an OG card component
-func (o *OGCardFetcher) GenerateOGCard(url string) (string, error) {
-	data, err := o.FetchOGData(url)
+func (o *OGCardFetcher) GenerateOGCard(ctx context.Context, url string) (string, error) {
+	data, err := o.FetchOGData(ctx, url)
 	if err != nil {
 		return "", err
 	}
@@ -101,6 +91,20 @@ func (o *OGCardFetcher) GenerateOGCard(url string) (string, error) {
 	return RenderOGCard(data), nil
 }
 
+// Match implements Provider for any http(s) URL
+func (o *OGCardFetcher) Match(url string) bool {
+	return strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://")
+}
+
+// Expand implements Provider by expanding the URL to an OG card
+func (o *OGCardFetcher) Expand(ctx context.Context, url string) (Embed, error) {
+	data, err := o.FetchOGData(ctx, url)
+	if err != nil {
+		return Embed{}, err
+	}
+	return Embed{OG: data}, nil
+}
+
 // RenderOGCard renders OGData as an HTML card component
 func RenderOGCard(data *OGData) string {
 	description := data.Description
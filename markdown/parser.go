@@ -2,6 +2,7 @@ package markdown
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -13,7 +14,9 @@ import (
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
 	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
 )
 
 // ArticleMeta represents the YAML frontmatter metadata
@@ -21,6 +24,9 @@ type ArticleMeta struct {
 	Title        string
 	DisplayTitle string // OG画像用タイトル（改行\nをサポート）
 	PublishedAt  time.Time
+	UpdatedAt    time.Time // 最終更新日（任意）
+	Tags         []string
+	OGTemplate   string // OG画像のテンプレート名（templates/<name>.png, 省略時はデフォルト）
 }
 
 // OGTitle returns the title for OG image (DisplayTitle if set, otherwise Title)
@@ -36,6 +42,8 @@ type ParsedArticle struct {
 	Meta     ArticleMeta
 	Content  string // HTML content
 	Filename string // filename without extension (e.g., "2026-01-22_created-my-own-blog")
+	// Embeds maps each standalone URL to its embed (nil if left as a link); only set with expansion
+	Embeds map[string]*Embed
 }
 
 // Parser handles markdown parsing with goldmark
@@ -73,25 +81,77 @@ func (ids *japaneseIDs) Put(value []byte) {
 	ids.values[string(value)] = 1
 }
 
+// Option configures a Parser
+type Option func(*parserConfig)
+
+// parserConfig holds the settings applied by Options
+type parserConfig struct {
+	providers []Provider
+	fetcher   *Fetcher
+	lock      *EmbedLock
+	lockMode  LockMode
+}
+
+// WithProviders registers additional embed providers.
+// They are tried in the given order before the built-in ones (see DefaultProviders).
+func WithProviders(providers ...Provider) Option {
+	return func(c *parserConfig) {
+		c.providers = append(c.providers, providers...)
+	}
+}
+
+// WithFetcher sets the Fetcher used by the built-in providers (NewFetcher by default)
+func WithFetcher(fetcher *Fetcher) Option {
+	return func(c *parserConfig) {
+		c.fetcher = fetcher
+	}
+}
+
+// WithEmbedLock reuses and records expanded embeds in lock according to mode
+func WithEmbedLock(lock *EmbedLock, mode LockMode) Option {
+	return func(c *parserConfig) {
+		c.lock = lock
+		c.lockMode = mode
+	}
+}
+
 // NewParser creates a new markdown parser with goldmark configuration
-func NewParser() *Parser {
+func NewParser(opts ...Option) *Parser {
+	cfg := parserConfig{fetcher: NewFetcher()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	md := goldmark.New(
 		goldmark.WithExtensions(
 			extension.GFM,
 			meta.Meta,
+			&containerExtension{},
+			&footnoteExtension{},
+			&mathExtension{},
 		),
 		goldmark.WithParserOptions(
 			parser.WithAutoHeadingID(),
+			parser.WithASTTransformers(
+				util.Prioritized(&expandTransformer{}, 100),
+			),
 		),
 		goldmark.WithRendererOptions(
 			html.WithHardWraps(),
 			html.WithXHTML(),
 			html.WithUnsafe(),
+			renderer.WithNodeRenderers(
+				util.Prioritized(&embedRenderer{}, 100),
+				util.Prioritized(&codeBlockRenderer{}, 100),
+			),
 		),
 	)
+	expander := NewExpander(append(cfg.providers, DefaultProviders(cfg.fetcher)...)...)
+	expander.lock = cfg.lock
+	expander.lockMode = cfg.lockMode
 	return &Parser{
 		md:       md,
-		expander: NewExpander(),
+		expander: expander,
 	}
 }
 
@@ -107,14 +167,17 @@ func (p *Parser) ParseFile(path string) (*ParsedArticle, error) {
 
 // Parse parses markdown content and returns a ParsedArticle
 func (p *Parser) Parse(source []byte, filename string) (*ParsedArticle, error) {
-	var buf bytes.Buffer
-	context := parser.NewContext(parser.WithIDs(newJapaneseIDs()))
+	return p.parse(source, filename, parser.NewContext(parser.WithIDs(newJapaneseIDs())))
+}
 
-	if err := p.md.Convert(source, &buf, parser.WithContext(context)); err != nil {
+// parse converts markdown content with the given parser context
+func (p *Parser) parse(source []byte, filename string, pc parser.Context) (*ParsedArticle, error) {
+	var buf bytes.Buffer
+	if err := p.md.Convert(source, &buf, parser.WithContext(pc)); err != nil {
 		return nil, err
 	}
 
-	metaData := meta.Get(context)
+	metaData := meta.Get(pc)
 	articleMeta := extractMeta(metaData)
 
 	return &ParsedArticle{
@@ -125,14 +188,21 @@ func (p *Parser) Parse(source []byte, filename string) (*ParsedArticle, error) {
 }
 
 // ParseWithExpansion parses markdown content with URL expansion
-// This expands GitHub URLs, Twitter embeds, and OG cards before parsing
+// Paragraphs consisting of a single URL are expanded by the registered providers
+// (GitHub code blocks, Twitter embeds, or OG cards by default)
 func (p *Parser) ParseWithExpansion(source []byte, filename string) (*ParsedArticle, error) {
-	// Expand special URLs
-	expanded := p.expander.ExpandContent(string(source))
 	// Process custom image widths
-	expanded = ProcessImageWidths(expanded)
+	expanded := ProcessImageWidths(string(source))
 
-	return p.Parse([]byte(expanded), filename)
+	pc := parser.NewContext(parser.WithIDs(newJapaneseIDs()))
+	exp := &expansion{ctx: context.Background(), expander: p.expander}
+	pc.Set(expanderKey, exp)
+	article, err := p.parse([]byte(expanded), filename, pc)
+	if err != nil {
+		return nil, err
+	}
+	article.Embeds = exp.embeds
+	return article, nil
 }
 
 // ParseFileWithExpansion parses a markdown file with URL expansion
@@ -164,6 +234,24 @@ func extractMeta(metaData map[string]interface{}) ArticleMeta {
 		}
 	}
 
+	if tags, ok := metaData["tags"].([]interface{}); ok {
+		for _, tag := range tags {
+			if s, ok := tag.(string); ok {
+				am.Tags = append(am.Tags, s)
+			}
+		}
+	}
+
+	if ogTemplate, ok := metaData["og_template"].(string); ok {
+		am.OGTemplate = ogTemplate
+	}
+
+	if updatedAt, ok := metaData["updated_at"].(string); ok {
+		if t, err := time.Parse("2006-01-02", updatedAt); err == nil {
+			am.UpdatedAt = t
+		}
+	}
+
 	return am
 }
 
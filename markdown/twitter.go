@@ -1,22 +1,22 @@
 package markdown
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"net/url"
 	"regexp"
 )
 
 // TwitterEmbedder handles Twitter/X tweet embedding
 type TwitterEmbedder struct {
-	client *http.Client
+	fetcher *Fetcher
 }
 
-// NewTwitterEmbedder creates a new TwitterEmbedder
-func NewTwitterEmbedder() *TwitterEmbedder {
+// NewTwitterEmbedder creates a new TwitterEmbedder that fetches with fetcher
+func NewTwitterEmbedder(fetcher *Fetcher) *TwitterEmbedder {
 	return &TwitterEmbedder{
-		client: &http.Client{},
+		fetcher: fetcher,
 	}
 }
 
@@ -36,24 +36,19 @@ type OEmbedResponse struct {
 }
 
 // GetEmbedHTML fetches the embed HTML for a tweet using Twitter oEmbed API
-func (t *TwitterEmbedder) GetEmbedHTML(tweetURL string) (string, error) {
+func (t *TwitterEmbedder) GetEmbedHTML(ctx context.Context, tweetURL string) (string, error) {
 	oembedURL := fmt.Sprintf(
 		"https://publish.twitter.com/oembed?url=%s&omit_script=true",
 		url.QueryEscape(tweetURL),
 	)
 
-	resp, err := t.client.Get(oembedURL)
+	body, err := t.fetcher.Get(ctx, oembedURL)
 	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("oEmbed API returned status %d", resp.StatusCode)
+		return "", fmt.Errorf("oEmbed API: %w", err)
 	}
 
 	var oembed OEmbedResponse
-	if err := json.NewDecoder(resp.Body).Decode(&oembed); err != nil {
+	if err := json.Unmarshal(body, &oembed); err != nil {
 		return "", err
 	}
 
@@ -61,11 +56,25 @@ func (t *TwitterEmbedder) GetEmbedHTML(tweetURL string) (string, error) {
 }
 
 // GenerateEmbed generates the full embed HTML including wrapper
-func (t *TwitterEmbedder) GenerateEmbed(tweetURL string) (string, error) {
-	embedHTML, err := t.GetEmbedHTML(tweetURL)
+func (t *TwitterEmbedder) GenerateEmbed(ctx context.Context, tweetURL string) (string, error) {
+	embedHTML, err := t.GetEmbedHTML(ctx, tweetURL)
 	if err != nil {
 		return "", err
 	}
 
 	return fmt.Sprintf(`<div class="twitter-embed">%s</div>`, embedHTML), nil
 }
+
+// Match implements Provider for Twitter/X status URLs
+func (t *TwitterEmbedder) Match(url string) bool {
+	return IsTwitterURL(url)
+}
+
+// Expand implements Provider by expanding the URL to an embedded tweet
+func (t *TwitterEmbedder) Expand(ctx context.Context, url string) (Embed, error) {
+	embedHTML, err := t.GenerateEmbed(ctx, url)
+	if err != nil {
+		return Embed{}, err
+	}
+	return Embed{HTML: embedHTML}, nil
+}
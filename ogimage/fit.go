@@ -0,0 +1,67 @@
+package ogimage
+
+import (
+	"math"
+	"strings"
+)
+
+// ellipsis is appended to truncated text
+const ellipsis = "…"
+
+// fitText wraps text at decreasing font sizes, from f.FontSize down to
+// f.MinFontSize, and returns the first size at which it fits the field's box.
+// If it never fits and f.Ellipsis is set, the text is truncated at the minimum size.
+func (g *Generator) fitText(f *Field, text string) (*fontSet, []string, error) {
+	maxWidth := float64(f.Box.Width)
+
+	var fs *fontSet
+	var lines []string
+	for size := f.FontSize; ; size -= f.Step {
+		// Make sure the last attempt is exactly the minimum size
+		size = math.Max(size, f.MinFontSize)
+
+		var err error
+		fs, err = g.fontsAt(size)
+		if err != nil {
+			return nil, nil, err
+		}
+		lines = fs.wrapTitle(text, maxWidth)
+		if len(lines) <= fs.maxLines(f) || size == f.MinFontSize {
+			break
+		}
+	}
+
+	if maxLines := fs.maxLines(f); f.Ellipsis && len(lines) > maxLines {
+		lines = fs.truncate(lines, maxLines, maxWidth)
+	}
+	return fs, lines, nil
+}
+
+// wrapTitle splits text by explicit newlines and wraps each line
+func (fs *fontSet) wrapTitle(text string, maxWidth float64) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, fs.wrapText(line, maxWidth)...)
+	}
+	return lines
+}
+
+// maxLines returns how many lines fit in the field's box at this size, capped by MaxLines
+func (fs *fontSet) maxLines(f *Field) int {
+	n := int(math.Floor(float64(f.Box.Height) / (fs.size * f.LineHeight)))
+	if f.MaxLines > 0 {
+		n = min(n, f.MaxLines)
+	}
+	return max(n, 1)
+}
+
+// truncate keeps the first n lines and ends the last one with an ellipsis
+func (fs *fontSet) truncate(lines []string, n int, maxWidth float64) []string {
+	lines = append([]string(nil), lines[:n]...)
+	last := []rune(strings.TrimSpace(lines[n-1]))
+	for len(last) > 0 && fs.measure(string(last)+ellipsis) > maxWidth {
+		last = last[:len(last)-1]
+	}
+	lines[n-1] = strings.TrimSpace(string(last)) + ellipsis
+	return lines
+}
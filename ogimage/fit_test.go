@@ -0,0 +1,115 @@
+package ogimage
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testField returns a validated field with a box of the given size in
+// characters of Go Mono at 20px (see testCharWidth)
+func testField(t *testing.T, f Field, cols, rows int) *Field {
+	t.Helper()
+	f.Box = Box{Width: cols * testCharWidth, Height: rows * 30}
+	layout := &Layout{Fields: []Field{f}}
+	if err := layout.validate(); err != nil {
+		t.Fatal(err)
+	}
+	return &layout.Fields[0]
+}
+
+func TestFitText(t *testing.T) {
+	tests := []struct {
+		name      string
+		field     Field
+		cols      int
+		rows      int
+		text      string
+		wantSize  float64
+		wantLines []string
+	}{
+		{
+			name:      "fits at the initial size",
+			field:     Field{FontSize: 20},
+			cols:      10,
+			rows:      1,
+			text:      "abcdefgh",
+			wantSize:  20,
+			wantLines: []string{"abcdefgh"},
+		},
+		{
+			// One line of 5 characters at 40px, of 6 at 30px, of 10 at 20px
+			name:      "shrinks until it fits",
+			field:     Field{FontSize: 40, MinFontSize: 20, Step: 10},
+			cols:      10,
+			rows:      2,
+			text:      "abcdefgh",
+			wantSize:  20,
+			wantLines: []string{"abcdefgh"},
+		},
+		{
+			name:      "stops at the minimum size",
+			field:     Field{FontSize: 40, MinFontSize: 25, Step: 10},
+			cols:      10,
+			rows:      2,
+			text:      "abcdefghijklmnopqrstuvwxyz",
+			wantSize:  25,
+			wantLines: []string{"abcdefgh", "ijklmnop", "qrstuvwx", "yz"},
+		},
+		{
+			name:      "ellipsis",
+			field:     Field{FontSize: 40, MinFontSize: 20, Step: 10, Ellipsis: true},
+			cols:      10,
+			rows:      1,
+			text:      "abcdefghijklmnopqrstuvwxyz",
+			wantSize:  20,
+			wantLines: []string{"abcdefghi…"},
+		},
+		{
+			name:      "ellipsis at max_lines",
+			field:     Field{FontSize: 20, MaxLines: 2, Ellipsis: true},
+			cols:      10,
+			rows:      3,
+			text:      "one two three four five",
+			wantSize:  20,
+			wantLines: []string{"one two", "three fou…"},
+		},
+	}
+	g := newTestGenerator(t, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs, lines, err := g.fitText(testField(t, tt.field, tt.cols, tt.rows), tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fs.size != tt.wantSize {
+				t.Errorf("size %v, want %v", fs.size, tt.wantSize)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("lines %q, want %q", lines, tt.wantLines)
+			}
+		})
+	}
+}
+
+func TestMaxLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		height   int
+		maxLines int
+		want     int
+	}{
+		{"lines that fit the box", 100, 0, 3}, // 100 / (20 * 1.5)
+		{"capped by max_lines", 100, 2, 2},
+		{"not capped by a larger max_lines", 100, 5, 3},
+		{"at least one line", 10, 0, 1},
+	}
+	fs := newTestFontSet(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Field{Box: Box{Height: tt.height}, LineHeight: 1.5, MaxLines: tt.maxLines}
+			if got := fs.maxLines(f); got != tt.want {
+				t.Errorf("maxLines = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package ogimage
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Card holds the values that can be drawn on an OG image
+type Card struct {
+	Title       string
+	PublishedAt time.Time
+	Tags        []string
+	SiteName    string
+}
+
+// Layout describes how a card is drawn on a template image.
+// It is stored as JSON next to the template (e.g. templates/blog-ogp-tmpl.json).
+type Layout struct {
+	Fields []Field `json:"fields"`
+}
+
+// Field is a text box on the template
+type Field struct {
+	// Field selects the card value to draw: "title", "published_at", "tags" or "site_name".
+	// If empty, Text is drawn as-is.
+	Field string `json:"field,omitempty"`
+	Text  string `json:"text,omitempty"`
+	// Format is the Go time layout for published_at (default "2006-01-02")
+	Format string `json:"format,omitempty"`
+	// Prefix and Separator are used for tags (e.g. "#" and " ")
+	Prefix    string `json:"prefix,omitempty"`
+	Separator string `json:"separator,omitempty"`
+
+	Box        Box     `json:"box"`
+	Align      string  `json:"align,omitempty"`  // "left", "center" (default) or "right"
+	VAlign     string  `json:"valign,omitempty"` // "top", "middle" (default) or "bottom"
+	Color      string  `json:"color,omitempty"`  // "#rrggbb" or "#rrggbbaa"
+	FontSize   float64 `json:"font_size"`
+	LineHeight float64 `json:"line_height,omitempty"` // multiple of the font size (default 1.5)
+
+	// MinFontSize enables auto-fit: the size shrinks by Step until the text fits Box
+	MinFontSize float64 `json:"min_font_size,omitempty"`
+	Step        float64 `json:"step,omitempty"`
+	// MaxLines limits the number of lines; 0 means as many as fit in Box
+	MaxLines int `json:"max_lines,omitempty"`
+	// Ellipsis truncates the text with "…" if it does not fit at MinFontSize
+	Ellipsis bool `json:"ellipsis,omitempty"`
+
+	color color.Color
+}
+
+// Box is a rectangle in template pixels
+type Box struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Rect returns the box as an image.Rectangle
+func (b Box) Rect() image.Rectangle {
+	return image.Rect(b.X, b.Y, b.X+b.Width, b.Y+b.Height)
+}
+
+// defaultTextColor is dark gray to match the site design
+var defaultTextColor = color.RGBA{R: 74, G: 75, B: 74, A: 255}
+
+// ParseLayout decodes and validates a JSON layout
+func ParseLayout(data []byte) (*Layout, error) {
+	var layout Layout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return nil, fmt.Errorf("ogimage: invalid layout: %w", err)
+	}
+	if err := layout.validate(); err != nil {
+		return nil, err
+	}
+	return &layout, nil
+}
+
+// DefaultLayout returns the ujiprog.com card design: the title centered in a
+// box 70% of the width and 4/7 of the height of the template
+func DefaultLayout(bounds image.Rectangle) *Layout {
+	width := bounds.Dx() * 7 / 10
+	height := bounds.Dy() * 4 / 7
+	layout := &Layout{
+		Fields: []Field{
+			{
+				Field: "title",
+				Box: Box{
+					X:      bounds.Min.X + (bounds.Dx()-width)/2,
+					Y:      bounds.Min.Y + (bounds.Dy()-height)/2,
+					Width:  width,
+					Height: height,
+				},
+				FontSize:    56,
+				MinFontSize: 32,
+				Step:        2,
+				MaxLines:    4,
+				Ellipsis:    true,
+			},
+		},
+	}
+	layout.validate()
+	return layout
+}
+
+// validate checks the layout and fills in defaults
+func (l *Layout) validate() error {
+	if len(l.Fields) == 0 {
+		return fmt.Errorf("ogimage: layout has no fields")
+	}
+	for i := range l.Fields {
+		f := &l.Fields[i]
+		switch f.Field {
+		case "", "title", "published_at", "tags", "site_name":
+		default:
+			return fmt.Errorf("ogimage: field %d: unknown field %q", i, f.Field)
+		}
+		switch f.Align {
+		case "", "left", "center", "right":
+		default:
+			return fmt.Errorf("ogimage: field %d: unknown align %q", i, f.Align)
+		}
+		switch f.VAlign {
+		case "", "top", "middle", "bottom":
+		default:
+			return fmt.Errorf("ogimage: field %d: unknown valign %q", i, f.VAlign)
+		}
+		if f.FontSize <= 0 {
+			return fmt.Errorf("ogimage: field %d: font_size must be positive", i)
+		}
+		if f.Box.Width <= 0 || f.Box.Height <= 0 {
+			return fmt.Errorf("ogimage: field %d: box must have a positive size", i)
+		}
+		if f.LineHeight <= 0 {
+			f.LineHeight = 1.5
+		}
+		if f.Step <= 0 {
+			f.Step = 2
+		}
+		if f.MinFontSize <= 0 || f.MinFontSize > f.FontSize {
+			f.MinFontSize = f.FontSize
+		}
+
+		f.color = defaultTextColor
+		if f.Color != "" {
+			c, err := parseColor(f.Color)
+			if err != nil {
+				return fmt.Errorf("ogimage: field %d: %w", i, err)
+			}
+			f.color = c
+		}
+	}
+	return nil
+}
+
+// text returns the string to draw for this field
+func (f *Field) text(card Card) string {
+	switch f.Field {
+	case "title":
+		return card.Title
+	case "published_at":
+		if card.PublishedAt.IsZero() {
+			return ""
+		}
+		format := f.Format
+		if format == "" {
+			format = "2006-01-02"
+		}
+		return card.PublishedAt.Format(format)
+	case "tags":
+		separator := f.Separator
+		if separator == "" {
+			separator = " "
+		}
+		tags := make([]string, len(card.Tags))
+		for i, tag := range card.Tags {
+			tags[i] = f.Prefix + tag
+		}
+		return strings.Join(tags, separator)
+	case "site_name":
+		return card.SiteName
+	default:
+		return f.Text
+	}
+}
+
+// parseColor parses "#rrggbb" or "#rrggbbaa"
+func parseColor(s string) (color.Color, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 && len(hex) != 8 {
+		return nil, fmt.Errorf("invalid color %q", s)
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid color %q", s)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
@@ -0,0 +1,205 @@
+package ogimage
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Line breaking for mixed Japanese / Latin titles.
+//
+// Text is split into tokens between which a line may break: whitespace runs,
+// Latin words (kept together) and single CJK characters. Kinsoku shori then
+// glues characters that must not start a line to the preceding token and
+// characters that must not end a line to the following token.
+
+// noStartChars must not appear at the start of a line (行頭禁則文字)
+const noStartChars = "、。，．,.・：；:;？！?!‼⁇⁈⁉" +
+	"）］｝〕〉》」』】〙〗〟’”｠»)]}" +
+	"ーゝゞヽヾ々〻" +
+	"ぁぃぅぇぉっゃゅょゎゕゖァィゥェォッャュョヮヵヶㇰㇱㇲㇳㇴㇵㇶㇷㇸㇹㇺㇻㇼㇽㇾㇿ" +
+	"…‥〜～" +
+	"｡､｣･ｧｨｩｪｫｬｭｮｯｰﾞﾟ" // halfwidth forms
+
+// noEndChars must not appear at the end of a line (行末禁則文字)
+const noEndChars = "（［｛〔〈《「『【〘〖〝‘“｟«([{｢"
+
+// minPreferredFill is the minimum fraction of the line width a line must fill
+// before a preferred break point (space or script boundary) is chosen over
+// breaking as late as possible
+const minPreferredFill = 0.6
+
+// tokenKind classifies a line breaking token
+type tokenKind int
+
+const (
+	tokenSpace tokenKind = iota
+	tokenWord            // run of Latin letters, digits and ASCII punctuation
+	tokenWide            // CJK character, kana, full-width form
+)
+
+// token is a unit of text that is never split across lines
+type token struct {
+	text string
+	kind tokenKind
+}
+
+// isWide reports whether r is an East Asian character that allows a line break on either side
+func isWide(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(r >= 0x3000 && r <= 0x303F) || // CJK Symbols and Punctuation
+		(r >= 0xFF00 && r <= 0xFFEF) || // Halfwidth and Fullwidth Forms
+		(r >= 0x2E80 && r <= 0x2FFF) || // CJK Radicals, Kangxi Radicals
+		(r >= 0x3200 && r <= 0x33FF) || // Enclosed CJK Letters, CJK Compatibility
+		r == 0x30FC // KATAKANA-HIRAGANA PROLONGED SOUND MARK
+}
+
+// tokenize splits text into tokens and applies kinsoku shori
+func tokenize(text string) []token {
+	var raw []token
+	for _, r := range text {
+		var kind tokenKind
+		switch {
+		case unicode.IsSpace(r):
+			kind = tokenSpace
+		case isWide(r):
+			kind = tokenWide
+		default:
+			kind = tokenWord
+		}
+
+		last := len(raw) - 1
+		switch {
+		case last >= 0 && isCombining(r):
+			// Variation selectors and joiners stay with their base character
+			raw[last].text += string(r)
+		case last >= 0 && kind != tokenWide && raw[last].kind == kind:
+			raw[last].text += string(r)
+		default:
+			raw = append(raw, token{text: string(r), kind: kind})
+		}
+	}
+
+	// Glue tokens according to kinsoku rules
+	var tokens []token
+	glueNext := false
+	for _, t := range raw {
+		last := len(tokens) - 1
+		first := []rune(t.text)[0]
+		switch {
+		case last >= 0 && glueNext && t.kind != tokenSpace:
+			tokens[last].text += t.text
+		case last >= 0 && tokens[last].kind != tokenSpace && strings.ContainsRune(noStartChars, first):
+			tokens[last].text += t.text
+		default:
+			tokens = append(tokens, t)
+		}
+		runes := []rune(t.text)
+		glueNext = t.kind != tokenSpace && strings.ContainsRune(noEndChars, runes[len(runes)-1])
+	}
+
+	return tokens
+}
+
+// preferredBreak reports whether a break between a and b is preferred:
+// at whitespace or where the text switches between Latin and Japanese
+func preferredBreak(a, b token) bool {
+	if a.kind == tokenSpace || b.kind == tokenSpace {
+		return true
+	}
+	return a.kind != b.kind
+}
+
+// joinTokens concatenates tokens, dropping whitespace at both ends
+func joinTokens(tokens []token) string {
+	var sb strings.Builder
+	for _, t := range tokens {
+		sb.WriteString(t.text)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// wrapText wraps text into lines no wider than maxWidth.
+// Latin words are kept together, kinsoku rules are respected and breaks at
+// spaces or Latin/Japanese boundaries are preferred when they leave the line
+// reasonably full. Tokens wider than a whole line are split per rune.
+func (fs *fontSet) wrapText(text string, maxWidth float64) []string {
+	var lines []string
+	var line []token
+
+	for _, t := range tokenize(text) {
+		if t.kind == tokenSpace && len(line) == 0 {
+			continue
+		}
+
+		for {
+			candidate := append(line[:len(line):len(line)], t)
+			if fs.measure(joinTokens(candidate)) <= maxWidth {
+				line = candidate
+				break
+			}
+
+			if len(line) == 0 {
+				// A single token does not fit: split it per rune
+				parts := fs.wrapRunes(t.text, maxWidth)
+				lines = append(lines, parts[:len(parts)-1]...)
+				line = []token{{text: parts[len(parts)-1], kind: t.kind}}
+				break
+			}
+
+			at := fs.chooseBreak(line, t, maxWidth)
+			lines = append(lines, joinTokens(line[:at]))
+			line = trimLeadingSpace(line[at:])
+			if t.kind == tokenSpace && len(line) == 0 {
+				break
+			}
+		}
+	}
+
+	if rest := joinTokens(line); rest != "" {
+		lines = append(lines, rest)
+	}
+	return lines
+}
+
+// chooseBreak returns the index in line to break at when next does not fit.
+// Breaking before next (len(line)) is the default; an earlier preferred break
+// point is used if the resulting line still fills minPreferredFill of maxWidth.
+func (fs *fontSet) chooseBreak(line []token, next token, maxWidth float64) int {
+	if preferredBreak(line[len(line)-1], next) {
+		return len(line)
+	}
+	for i := len(line) - 1; i > 0; i-- {
+		if !preferredBreak(line[i-1], line[i]) {
+			continue
+		}
+		if fs.measure(joinTokens(line[:i])) >= maxWidth*minPreferredFill {
+			return i
+		}
+		break
+	}
+	return len(line)
+}
+
+// trimLeadingSpace drops whitespace tokens at the start of a line
+func trimLeadingSpace(line []token) []token {
+	for len(line) > 0 && line[0].kind == tokenSpace {
+		line = line[1:]
+	}
+	return line
+}
+
+// wrapRunes wraps text rune by rune; used for words longer than a line
+func (fs *fontSet) wrapRunes(text string, maxWidth float64) []string {
+	var lines []string
+	currentLine := ""
+	for _, r := range text {
+		testLine := currentLine + string(r)
+		if currentLine == "" || isCombining(r) || fs.measure(testLine) <= maxWidth {
+			currentLine = testLine
+		} else {
+			lines = append(lines, currentLine)
+			currentLine = string(r)
+		}
+	}
+	return append(lines, currentLine)
+}
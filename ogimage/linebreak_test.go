@@ -0,0 +1,100 @@
+package ogimage
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"reflect"
+	"testing"
+
+	"golang.org/x/image/font/gofont/gomono"
+)
+
+// newTestGenerator returns a Generator with only Go Mono, in which every glyph
+// (including the missing glyph drawn for Japanese text) has the same advance
+func newTestGenerator(t *testing.T, layout *Layout) *Generator {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1200, 630))); err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGenerator(buf.Bytes(), [][]byte{gomono.TTF}, layout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// testCharWidth is the advance of every character of Go Mono at 20px
+const testCharWidth = 12
+
+// newTestFontSet returns the test fonts at 20px
+func newTestFontSet(t *testing.T) *fontSet {
+	t.Helper()
+	fs, err := newTestGenerator(t, nil).fontsAt(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := fs.measure("x"); w != testCharWidth || fs.measure("あ") != w {
+		t.Fatalf("unexpected advance %v", w)
+	}
+	return fs
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"punctuation", "です。次、", []string{"で", "す。", "次、"}},
+		{"prolonged sound mark", "ラーメン", []string{"ラー", "メ", "ン"}},
+		{"small kana", "ちょっと", []string{"ちょっ", "と"}},
+		{"repeated marks", "最高！！", []string{"最", "高！！"}},
+		{"halfwidth katakana", "ｶｰﾄﾞ", []string{"ｶｰ", "ﾄﾞ"}},
+		{"brackets", "「はい」と", []string{"「は", "い」", "と"}},
+		{"fullwidth parentheses", "（注）です", []string{"（注）", "で", "す"}},
+		{"words", "Hello, world", []string{"Hello,", " ", "world"}},
+		{"mixed", "syumai/workers(Go) で作った", []string{"syumai/workers(Go)", " ", "で", "作っ", "た"}},
+		{"word then Japanese", "Goで", []string{"Go", "で"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, token := range tokenize(tt.text) {
+				got = append(got, token.text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		chars int // line width in characters
+		want  []string
+	}{
+		{"fits", "Hello", 10, []string{"Hello"}},
+		{"break at spaces", "Hello world foo", 11, []string{"Hello world", "foo"}},
+		{"no word is split", "Hello world", 8, []string{"Hello", "world"}},
+		{"line-start prohibited", "あいうえお。", 5, []string{"あいうえ", "お。"}},
+		{"line-end prohibited", "「はい」と言った", 3, []string{"「は", "い」と", "言った"}},
+		// The overlong word is split per rune, then the space before Japanese
+		// is preferred over filling the line
+		{"overlong word", "syumai/workers(Go) で作った", 10, []string{"syumai/wor", "kers(Go)", "で作った"}},
+		{"leading spaces dropped", "  abc", 5, []string{"abc"}},
+	}
+	fs := newTestFontSet(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fs.wrapText(tt.text, float64(tt.chars*testCharWidth))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("wrapText(%q, %d chars) = %q, want %q", tt.text, tt.chars, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package ogimage
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// SiteName is drawn by layouts that contain a site_name field
+const SiteName = "ujiprog.com"
+
+// DefaultTemplate is the card template used when an article does not set og_template
+const DefaultTemplate = "blog-ogp-tmpl"
+
+// templateNamePattern restricts template names to plain file names, since they
+// come from article frontmatter and are used in file paths and bucket keys
+var templateNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Meta is the OG image metadata of an article, stored in og-meta.json by
+// cmd/generate and read by the worker to render the image on demand
+type Meta struct {
+	Title       string   `json:"title"` // may contain explicit line breaks
+	PublishedAt string   `json:"published_at,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Template    string   `json:"template,omitempty"` // templates/<name>.png (default if empty)
+}
+
+// TemplateName returns the card template to render m with
+func (m Meta) TemplateName() (string, error) {
+	if m.Template == "" {
+		return DefaultTemplate, nil
+	}
+	if !templateNamePattern.MatchString(m.Template) {
+		return "", fmt.Errorf("ogimage: invalid template name %q", m.Template)
+	}
+	return m.Template, nil
+}
+
+// Card converts the metadata to the values drawn on the image
+func (m Meta) Card() Card {
+	card := Card{
+		Title:    m.Title,
+		Tags:     m.Tags,
+		SiteName: SiteName,
+	}
+	if t, err := time.Parse(time.RFC3339, m.PublishedAt); err == nil {
+		card.PublishedAt = t
+	}
+	return card
+}
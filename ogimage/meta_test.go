@@ -0,0 +1,41 @@
+package ogimage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMetaTemplateName(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{"", DefaultTemplate, false},
+		{"talk-ogp_2", "talk-ogp_2", false},
+		{"../x", "", true},
+		{"a/b", "", true},
+		{"x.png", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Meta{Template: tt.template}.TemplateName()
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("TemplateName(%q) = %q, %v", tt.template, got, err)
+		}
+	}
+}
+
+func TestMetaCard(t *testing.T) {
+	card := Meta{Title: "タイトル", PublishedAt: "2026-01-22T00:00:00+09:00", Tags: []string{"go"}}.Card()
+	if card.Title != "タイトル" || card.SiteName != SiteName || len(card.Tags) != 1 {
+		t.Errorf("card %+v", card)
+	}
+	if want := time.Date(2026, 1, 22, 0, 0, 0, 0, time.FixedZone("", 9*60*60)); !card.PublishedAt.Equal(want) {
+		t.Errorf("PublishedAt %v, want %v", card.PublishedAt, want)
+	}
+
+	// An invalid date is left out of the card
+	if card := (Meta{PublishedAt: "yesterday"}).Card(); !card.PublishedAt.IsZero() {
+		t.Errorf("PublishedAt %v, want zero", card.PublishedAt)
+	}
+}
@@ -2,6 +2,8 @@ package ogimage
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
@@ -15,244 +17,255 @@ import (
 	"golang.org/x/image/math/fixed"
 )
 
-// Generator generates OG images for articles (WASM compatible)
+// Generator generates OG images for articles (WASM compatible).
+// A Generator is not safe for concurrent use.
 type Generator struct {
-	templateImg  image.Image
-	asciiFace    font.Face
-	japaneseFace font.Face
-	fontSize     float64
+	templateImg image.Image
+	fonts       []*sfnt.Font // fallback chain, in order of preference
+	layout      *Layout
+	fontSets    map[float64]*fontSet
+	buf         sfnt.Buffer
 }
 
-// NewGenerator creates a new OG image generator from byte slices
-func NewGenerator(templateData, asciiFontData, japaneseFontData []byte, fontSize float64) (*Generator, error) {
+// NewGenerator creates a new OG image generator from byte slices.
+// fonts is an ordered fallback chain: each rune is drawn with the first font
+// that has a glyph for it (e.g. Latin, then Japanese, then emoji).
+// layout describes where card fields are drawn; nil means DefaultLayout.
+func NewGenerator(templateData []byte, fonts [][]byte, layout *Layout) (*Generator, error) {
 	// Decode template image
 	templateImg, err := png.Decode(bytes.NewReader(templateData))
 	if err != nil {
 		return nil, err
 	}
 
-	gen := &Generator{
-		templateImg: templateImg,
-		fontSize:    fontSize,
+	if len(fonts) == 0 {
+		return nil, errors.New("ogimage: no fonts given")
 	}
 
-	// Load ASCII font
-	if len(asciiFontData) > 0 {
-		face, err := loadFontFace(asciiFontData, fontSize)
-		if err == nil {
-			gen.asciiFace = face
-		}
+	if layout == nil {
+		layout = DefaultLayout(templateImg.Bounds())
+	}
+
+	gen := &Generator{
+		templateImg: templateImg,
+		layout:      layout,
+		fontSets:    make(map[float64]*fontSet),
 	}
 
-	// Load Japanese font
-	if len(japaneseFontData) > 0 {
-		face, err := loadFontFace(japaneseFontData, fontSize)
-		if err == nil {
-			gen.japaneseFace = face
+	for i, data := range fonts {
+		f, err := sfnt.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("ogimage: font %d: %w", i, err)
 		}
+		gen.fonts = append(gen.fonts, f)
 	}
 
 	return gen, nil
 }
 
-// loadFontFace loads a font from byte slice and returns a font.Face
-func loadFontFace(data []byte, size float64) (font.Face, error) {
-	f, err := sfnt.Parse(data)
-	if err != nil {
-		return nil, err
+// fontSet is the font fallback chain instantiated at one size
+type fontSet struct {
+	g     *Generator
+	size  float64
+	faces []font.Face
+}
+
+// fontsAt returns the font chain at the given size, creating faces on first use
+func (g *Generator) fontsAt(size float64) (*fontSet, error) {
+	if fs, ok := g.fontSets[size]; ok {
+		return fs, nil
+	}
+
+	fs := &fontSet{g: g, size: size}
+	for _, f := range g.fonts {
+		face, err := opentype.NewFace(f, &opentype.FaceOptions{
+			Size:    size,
+			DPI:     72,
+			Hinting: font.HintingFull,
+		})
+		if err != nil {
+			return nil, err
+		}
+		fs.faces = append(fs.faces, face)
 	}
 
-	return opentype.NewFace(f, &opentype.FaceOptions{
-		Size:    size,
-		DPI:     72,
-		Hinting: font.HintingFull,
-	})
+	g.fontSets[size] = fs
+	return fs, nil
 }
 
-// textSegment represents a segment of text with its font type
+// textSegment represents a run of text drawn with a single font
 type textSegment struct {
-	text       string
-	isJapanese bool
+	text string
+	font int // index into Generator.fonts
 }
 
-// isJapanese checks if a rune is a Japanese character
-func isJapanese(r rune) bool {
-	return (r >= 0x3040 && r <= 0x309F) || // ひらがな
-		(r >= 0x30A0 && r <= 0x30FF) || // カタカナ
-		(r >= 0x4E00 && r <= 0x9FFF) || // 漢字
-		(r >= 0x3000 && r <= 0x303F) || // CJK句読点
-		(r >= 0xFF00 && r <= 0xFFEF) // 全角文字
+// isCombining reports whether r modifies the preceding rune and must be drawn
+// with the same font (variation selectors, ZWJ, skin tone modifiers, keycaps)
+func isCombining(r rune) bool {
+	return r == 0x200D || // ZERO WIDTH JOINER
+		(r >= 0xFE00 && r <= 0xFE0F) || // Variation Selectors
+		(r >= 0x1F3FB && r <= 0x1F3FF) || // Emoji skin tone modifiers
+		r == 0x20E3 || // COMBINING ENCLOSING KEYCAP
+		(r >= 0xE0100 && r <= 0xE01EF) // Variation Selectors Supplement
 }
 
-// segmentText splits text into segments based on character type (ASCII vs Japanese)
-func segmentText(text string) []textSegment {
+// fontFor returns the index of the first font in the chain that has a glyph for r.
+// If no font covers r, the first font is used so the missing glyph stays visible.
+func (g *Generator) fontFor(r rune) int {
+	for i, f := range g.fonts {
+		idx, err := f.GlyphIndex(&g.buf, r)
+		if err == nil && idx != 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+// segmentText splits text into runs that share the same font
+func (g *Generator) segmentText(text string) []textSegment {
 	var segments []textSegment
 	var current strings.Builder
-	var currentIsJapanese *bool
+	currentFont := -1
 
 	for _, r := range text {
-		isJP := isJapanese(r)
-
-		if currentIsJapanese == nil {
-			currentIsJapanese = &isJP
-			current.WriteRune(r)
-		} else if *currentIsJapanese == isJP {
-			current.WriteRune(r)
-		} else {
-			// Character type changed, save current segment
+		fontIdx := currentFont
+		if currentFont == -1 || !isCombining(r) {
+			fontIdx = g.fontFor(r)
+		}
+
+		if fontIdx != currentFont && current.Len() > 0 {
+			// Font changed, save current segment
 			segments = append(segments, textSegment{
-				text:       current.String(),
-				isJapanese: *currentIsJapanese,
+				text: current.String(),
+				font: currentFont,
 			})
 			current.Reset()
-			currentIsJapanese = &isJP
-			current.WriteRune(r)
 		}
+		currentFont = fontIdx
+		current.WriteRune(r)
 	}
 
 	// Add final segment
-	if current.Len() > 0 && currentIsJapanese != nil {
+	if current.Len() > 0 {
 		segments = append(segments, textSegment{
-			text:       current.String(),
-			isJapanese: *currentIsJapanese,
+			text: current.String(),
+			font: currentFont,
 		})
 	}
 
 	return segments
 }
 
-// drawTextDirect draws text directly using font.Drawer for accurate glyph coverage
-func (g *Generator) drawTextDirect(dst draw.Image, line string, centerX, y float64, textColor color.Color) {
-	segments := segmentText(line)
-	totalWidth := g.measureLineWidth(line)
+// ascent returns the largest ascent in the font chain so that baselines do
+// not move depending on which fonts a line happens to use
+func (fs *fontSet) ascent() fixed.Int26_6 {
+	var ascent fixed.Int26_6
+	for _, face := range fs.faces {
+		if a := face.Metrics().Ascent; a > ascent {
+			ascent = a
+		}
+	}
+	return ascent
+}
 
-	// Calculate starting X position for centered text
-	x := int(centerX - totalWidth/2)
+// measure measures the width of a line considering multiple fonts
+func (fs *fontSet) measure(line string) float64 {
+	var totalWidth fixed.Int26_6
+	for _, seg := range fs.g.segmentText(line) {
+		totalWidth += font.MeasureString(fs.faces[seg.font], seg.text)
+	}
 
-	// Calculate baseline Y position (y is vertical center, adjust for ascent)
-	var ascent fixed.Int26_6
-	if g.japaneseFace != nil {
-		ascent = g.japaneseFace.Metrics().Ascent
-	} else if g.asciiFace != nil {
-		ascent = g.asciiFace.Metrics().Ascent
+	return float64(totalWidth) / 64.0 // Convert from fixed.Int26_6 to float64
+}
+
+// drawLine draws text directly using font.Drawer for accurate glyph coverage.
+// x is the left edge, center or right edge of the line depending on align,
+// and y is the vertical center of the line.
+func (fs *fontSet) drawLine(dst draw.Image, line string, x, y float64, align string, textColor color.Color) {
+	// Calculate starting X position
+	width := fs.measure(line)
+	switch align {
+	case "left":
+	case "right":
+		x -= width
+	default:
+		x -= width / 2
 	}
-	yBaseline := int(y) + ascent.Round()/2
+	dotX := int(x)
 
-	src := image.NewUniform(textColor)
+	// Calculate baseline Y position (y is vertical center, adjust for ascent)
+	yBaseline := int(y) + fs.ascent().Round()/2
 
-	for _, seg := range segments {
-		var face font.Face
-		if seg.isJapanese && g.japaneseFace != nil {
-			face = g.japaneseFace
-		} else if g.asciiFace != nil {
-			face = g.asciiFace
-		} else {
-			continue
-		}
+	src := image.NewUniform(textColor)
 
+	for _, seg := range fs.g.segmentText(line) {
 		d := &font.Drawer{
 			Dst:  dst,
 			Src:  src,
-			Face: face,
-			Dot:  fixed.P(x, yBaseline),
+			Face: fs.faces[seg.font],
+			Dot:  fixed.P(dotX, yBaseline),
 		}
 		d.DrawString(seg.text)
-		x = d.Dot.X.Round()
+		dotX = d.Dot.X.Round()
 	}
 }
 
-// Generate creates an OG image with the given title and writes it to the writer
-func (g *Generator) Generate(title string, w io.Writer) error {
+// Generate draws the card onto the template following the layout and writes it as PNG
+func (g *Generator) Generate(card Card, w io.Writer) error {
 	bounds := g.templateImg.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
 
 	// Create RGBA image and draw template as background
 	dst := image.NewRGBA(bounds)
 	draw.Draw(dst, bounds, g.templateImg, bounds.Min, draw.Src)
 
-	// Text color (dark gray to match site design)
-	textColor := color.RGBA{R: 74, G: 75, B: 74, A: 255}
-
-	// Calculate text area
-	textAreaX := float64(width) / 2
-	textAreaY := float64(height) / 2
-	maxWidth := float64(width) * 0.7
-
-	// Split by explicit newlines first
-	explicitLines := strings.Split(title, "\n")
-
-	// Wrap each line if necessary
-	var allLines []string
-	for _, line := range explicitLines {
-		wrapped := g.wrapTextMultiFont(line, maxWidth)
-		allLines = append(allLines, wrapped...)
-	}
-
-	// Calculate total height of text block
-	lineHeight := g.fontSize * 1.5
-	totalTextHeight := float64(len(allLines)) * lineHeight
-
-	// Draw each line centered using direct font.Drawer (accurate glyph coverage)
-	startY := textAreaY - totalTextHeight/2 + lineHeight/2
-	for i, line := range allLines {
-		y := startY + float64(i)*lineHeight
-		g.drawTextDirect(dst, line, textAreaX, y, textColor)
+	for i := range g.layout.Fields {
+		f := &g.layout.Fields[i]
+		text := f.text(card)
+		if text == "" {
+			continue
+		}
+		if err := g.drawField(dst, f, text); err != nil {
+			return err
+		}
 	}
 
 	// Encode to PNG
 	return png.Encode(w, dst)
 }
 
-// wrapTextMultiFont wraps text considering multiple fonts
-func (g *Generator) wrapTextMultiFont(text string, maxWidth float64) []string {
-	var lines []string
-
-	// Measure and wrap character by character for accuracy with mixed fonts
-	runes := []rune(text)
-	if len(runes) == 0 {
-		return lines
+// drawField fits text into the field's box and draws it with the field's alignment
+func (g *Generator) drawField(dst draw.Image, f *Field, text string) error {
+	fs, lines, err := g.fitText(f, text)
+	if err != nil {
+		return err
 	}
 
-	currentLine := ""
-	for _, r := range runes {
-		testLine := currentLine + string(r)
-		lineWidth := g.measureLineWidth(testLine)
-
-		if lineWidth <= maxWidth {
-			currentLine = testLine
-		} else {
-			if currentLine != "" {
-				lines = append(lines, currentLine)
-			}
-			currentLine = string(r)
-		}
+	box := f.Box.Rect()
+	var x float64
+	switch f.Align {
+	case "left":
+		x = float64(box.Min.X)
+	case "right":
+		x = float64(box.Max.X)
+	default:
+		x = float64(box.Min.X+box.Max.X) / 2
 	}
 
-	if currentLine != "" {
-		lines = append(lines, currentLine)
+	lineHeight := fs.size * f.LineHeight
+	totalTextHeight := float64(len(lines)) * lineHeight
+	var startY float64
+	switch f.VAlign {
+	case "top":
+		startY = float64(box.Min.Y) + lineHeight/2
+	case "bottom":
+		startY = float64(box.Max.Y) - totalTextHeight + lineHeight/2
+	default:
+		startY = float64(box.Min.Y+box.Max.Y)/2 - totalTextHeight/2 + lineHeight/2
 	}
 
-	return lines
-}
-
-// measureLineWidth measures the width of a line considering multiple fonts
-func (g *Generator) measureLineWidth(line string) float64 {
-	segments := segmentText(line)
-	var totalWidth fixed.Int26_6
-
-	for _, seg := range segments {
-		var face font.Face
-		if seg.isJapanese && g.japaneseFace != nil {
-			face = g.japaneseFace
-		} else if g.asciiFace != nil {
-			face = g.asciiFace
-		} else {
-			continue
-		}
-		w := font.MeasureString(face, seg.text)
-		totalWidth += w
+	for i, line := range lines {
+		y := startY + float64(i)*lineHeight
+		fs.drawLine(dst, line, x, y, f.Align, f.color)
 	}
-
-	return float64(totalWidth) / 64.0 // Convert from fixed.Int26_6 to float64
+	return nil
 }
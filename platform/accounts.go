@@ -0,0 +1,50 @@
+package platform
+
+// Accounts names the user on each platform. Platforms with an empty name are skipped.
+type Accounts struct {
+	Zenn        string // username
+	Note        string // creator ID
+	SpeakerDeck string // username
+	Qiita       string // user ID
+	Hatena      string // blog host, e.g. example.hatenablog.com
+	DevTo       string // username
+	Connpass    string // nickname to list talks for
+	// ConnpassAPIKey is required by the connpass API
+	ConnpassAPIKey string
+}
+
+// DefaultAccounts returns the accounts listed on the site
+func DefaultAccounts() Accounts {
+	return Accounts{
+		Zenn:        "uji",
+		Note:        "ujiii",
+		SpeakerDeck: "uji",
+	}
+}
+
+// Sources returns a source for each platform with an account, fetching with client
+func (a Accounts) Sources(client Client) []Source {
+	var sources []Source
+	if a.Zenn != "" {
+		sources = append(sources, NewZenn(client, a.Zenn))
+	}
+	if a.Note != "" {
+		sources = append(sources, NewNote(client, a.Note))
+	}
+	if a.SpeakerDeck != "" {
+		sources = append(sources, NewSpeakerDeck(client, a.SpeakerDeck))
+	}
+	if a.Qiita != "" {
+		sources = append(sources, NewQiita(client, a.Qiita))
+	}
+	if a.Hatena != "" {
+		sources = append(sources, NewHatena(client, a.Hatena))
+	}
+	if a.DevTo != "" {
+		sources = append(sources, NewDevTo(client, a.DevTo))
+	}
+	if a.Connpass != "" {
+		sources = append(sources, NewConnpass(client, a.Connpass, a.ConnpassAPIKey))
+	}
+	return sources
+}
@@ -0,0 +1,66 @@
+package platform
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+)
+
+// atomLink is a link element of an Atom feed or entry
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+// atomFeed is the subset of an Atom feed used for the article list
+type atomFeed struct {
+	Links   []atomLink `xml:"link"`
+	Entries []struct {
+		Title     string     `xml:"title"`
+		Published string     `xml:"published"`
+		Updated   string     `xml:"updated"`
+		Links     []atomLink `xml:"link"`
+	} `xml:"entry"`
+}
+
+// alternateLink returns the rel="alternate" link (the default relation when rel is omitted)
+func alternateLink(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "alternate" || l.Rel == "" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// fetchAtom fetches an Atom feed, following rel="next" links for up to maxPages pages
+func fetchAtom(ctx context.Context, client Client, url, platformName string, maxPages int) ([]Article, error) {
+	var articles []Article
+	for page := 0; url != "" && page < maxPages; page++ {
+		body, err := get(ctx, client, url)
+		if err != nil {
+			return nil, err
+		}
+
+		var feed atomFeed
+		if err := xml.Unmarshal(body, &feed); err != nil {
+			return nil, fmt.Errorf("failed to parse %s feed: %w", platformName, err)
+		}
+		for _, e := range feed.Entries {
+			articles = append(articles, Article{
+				Title:       e.Title,
+				URL:         alternateLink(e.Links),
+				PublishedAt: e.Published,
+				Platform:    platformName,
+			})
+		}
+
+		url = ""
+		for _, l := range feed.Links {
+			if l.Rel == "next" {
+				url = l.Href
+			}
+		}
+	}
+	return articles, nil
+}
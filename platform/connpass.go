@@ -0,0 +1,84 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Connpass fetches the events a user presented at from the connpass API (v2).
+// The API requires an API key.
+type Connpass struct {
+	client   Client
+	baseURL  string
+	nickname string
+	apiKey   string
+}
+
+// NewConnpass creates a connpass source for the user nickname
+func NewConnpass(client Client, nickname, apiKey string) *Connpass {
+	return &Connpass{client: client, baseURL: "https://connpass.com", nickname: nickname, apiKey: apiKey}
+}
+
+// connpassResponse is a page of GET /api/v2/users/{nickname}/presenter_events/
+type connpassResponse struct {
+	ResultsReturned  int `json:"results_returned"`
+	ResultsAvailable int `json:"results_available"`
+	ResultsStart     int `json:"results_start"`
+	Events           []struct {
+		Title     string `json:"title"`
+		URL       string `json:"url"`
+		StartedAt string `json:"started_at"`
+	} `json:"events"`
+}
+
+// connpassCount is the page size requested from the connpass API
+const connpassCount = 100
+
+// Platform implements Source
+func (c *Connpass) Platform() string {
+	return "connpass"
+}
+
+// Fetch implements Source, following the pagination of the API.
+// Talks are dated by the start of the event.
+func (c *Connpass) Fetch(ctx context.Context) ([]Article, error) {
+	if c.apiKey == "" {
+		return nil, fmt.Errorf("connpass API key is not set")
+	}
+
+	var articles []Article
+	for start := 1; ; {
+		apiURL := fmt.Sprintf("%s/api/v2/users/%s/presenter_events/?start=%d&count=%d",
+			c.baseURL, url.PathEscape(c.nickname), start, connpassCount)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-API-Key", c.apiKey)
+		body, err := do(c.client, req)
+		if err != nil {
+			return nil, err
+		}
+
+		var resp connpassResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, fmt.Errorf("failed to parse connpass response: %w", err)
+		}
+		for _, e := range resp.Events {
+			articles = append(articles, Article{
+				Title:       e.Title,
+				URL:         e.URL,
+				PublishedAt: e.StartedAt,
+				Platform:    c.Platform(),
+			})
+		}
+
+		start += resp.ResultsReturned
+		if resp.ResultsReturned == 0 || start > resp.ResultsAvailable {
+			return articles, nil
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// DevTo fetches articles from the dev.to (Forem) API
+type DevTo struct {
+	client   Client
+	baseURL  string
+	username string
+}
+
+// NewDevTo creates a dev.to source for username
+func NewDevTo(client Client, username string) *DevTo {
+	return &DevTo{client: client, baseURL: "https://dev.to", username: username}
+}
+
+// devToArticle is an element of GET /api/articles
+type devToArticle struct {
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	PublishedAt string `json:"published_at"`
+	EditedAt    string `json:"edited_at"`
+}
+
+// devToPerPage is the page size requested from the dev.to API
+const devToPerPage = 100
+
+// maxDevToPages bounds the pagination in case the API keeps returning full pages
+const maxDevToPages = 50
+
+// Platform implements Source
+func (d *DevTo) Platform() string {
+	return "devto"
+}
+
+// Fetch implements Source, following the pagination of the API
+func (d *DevTo) Fetch(ctx context.Context) ([]Article, error) {
+	var articles []Article
+	for page := 1; page <= maxDevToPages; page++ {
+		apiURL := fmt.Sprintf("%s/api/articles?username=%s&page=%d&per_page=%d",
+			d.baseURL, url.QueryEscape(d.username), page, devToPerPage)
+		body, err := get(ctx, d.client, apiURL)
+		if err != nil {
+			return nil, err
+		}
+
+		var items []devToArticle
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, fmt.Errorf("failed to parse dev.to response: %w", err)
+		}
+		for _, item := range items {
+			articles = append(articles, Article{
+				Title:       item.Title,
+				URL:         item.URL,
+				PublishedAt: item.PublishedAt,
+				UpdatedAt:   item.EditedAt,
+				Platform:    d.Platform(),
+			})
+		}
+
+		if len(items) < devToPerPage {
+			break
+		}
+	}
+	return articles, nil
+}
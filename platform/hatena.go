@@ -0,0 +1,27 @@
+package platform
+
+import "context"
+
+// Hatena fetches articles from the Atom feed of a Hatena Blog
+type Hatena struct {
+	client Client
+	host   string
+}
+
+// NewHatena creates a Hatena Blog source for the blog host (e.g. "example.hatenablog.com")
+func NewHatena(client Client, host string) *Hatena {
+	return &Hatena{client: client, host: host}
+}
+
+// maxHatenaPages bounds the feed pagination (the feed lists 30 entries per page)
+const maxHatenaPages = 20
+
+// Platform implements Source
+func (h *Hatena) Platform() string {
+	return "hatena"
+}
+
+// Fetch implements Source, following the pagination of the feed
+func (h *Hatena) Fetch(ctx context.Context) ([]Article, error) {
+	return fetchAtom(ctx, h.client, "https://"+h.host+"/feed", h.Platform(), maxHatenaPages)
+}
@@ -0,0 +1,78 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Encode returns data as the indented JSON written to articles.json
+func (d ArticlesData) Encode() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// Merge replaces the articles of the given platforms in data with articles,
+// keeping the others, and returns them sorted by publication date, newest first.
+// Articles published at the same time are ordered by URL so the output is stable.
+func Merge(data ArticlesData, platforms []string, articles []Article) ArticlesData {
+	// Keep the existing articles of other platforms, then add the new ones.
+	// A later article with the same URL replaces the earlier one in place.
+	var merged []Article
+	index := make(map[string]int)
+	add := func(a Article) {
+		if i, ok := index[a.URL]; ok {
+			merged[i] = a
+			return
+		}
+		index[a.URL] = len(merged)
+		merged = append(merged, a)
+	}
+	for _, a := range data.Articles {
+		if !slices.Contains(platforms, a.Platform) {
+			add(a)
+		}
+	}
+	for _, a := range articles {
+		add(a)
+	}
+
+	// Sort by published_at descending. Dates are compared as instants because the
+	// platforms report them in different time zones; unparsable dates sort last.
+	published := make(map[string]time.Time, len(merged))
+	for _, a := range merged {
+		published[a.URL], _ = time.Parse(time.RFC3339, a.PublishedAt)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		ti, tj := published[merged[i].URL], published[merged[j].URL]
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return merged[i].URL < merged[j].URL
+	})
+
+	return ArticlesData{Articles: merged}
+}
+
+// Refresh fetches sources and merges the articles of the platforms that succeeded into data.
+// Platforms that fail keep their previous articles; it is an error if all of them fail.
+func Refresh(ctx context.Context, data ArticlesData, sources []Source) (ArticlesData, error) {
+	var platforms []string
+	var articles []Article
+	for _, result := range FetchAll(ctx, sources) {
+		if result.Err != nil {
+			log.Printf("Warning: Failed to fetch %s, keeping its existing articles: %v", result.Platform, result.Err)
+			continue
+		}
+		log.Printf("Fetched %d articles from %s", len(result.Articles), result.Platform)
+		platforms = append(platforms, result.Platform)
+		articles = append(articles, result.Articles...)
+	}
+	if len(platforms) == 0 {
+		return data, errors.New("failed to fetch any platform")
+	}
+	return Merge(data, platforms, articles), nil
+}
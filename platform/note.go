@@ -0,0 +1,71 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// Note fetches articles from the note creator API
+type Note struct {
+	client  Client
+	baseURL string
+	creator string
+}
+
+// NewNote creates a note source for the creator ID
+func NewNote(client Client, creator string) *Note {
+	return &Note{client: client, baseURL: "https://note.com", creator: creator}
+}
+
+// noteResponse is a page of GET /api/v2/creators/{creator}/contents
+type noteResponse struct {
+	Data struct {
+		Contents []struct {
+			Name      string `json:"name"`
+			NoteURL   string `json:"noteUrl"`
+			PublishAt string `json:"publishAt"`
+		} `json:"contents"`
+		IsLastPage bool `json:"isLastPage"`
+	} `json:"data"`
+}
+
+// maxNotePages bounds the pagination in case the API never reports the last page
+const maxNotePages = 50
+
+// Platform implements Source
+func (n *Note) Platform() string {
+	return "note"
+}
+
+// Fetch implements Source, following the pagination of the API
+func (n *Note) Fetch(ctx context.Context) ([]Article, error) {
+	var articles []Article
+	for page := 1; page <= maxNotePages; page++ {
+		apiURL := fmt.Sprintf("%s/api/v2/creators/%s/contents?kind=note&page=%d",
+			n.baseURL, url.PathEscape(n.creator), page)
+		body, err := get(ctx, n.client, apiURL)
+		if err != nil {
+			return nil, err
+		}
+
+		var resp noteResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, fmt.Errorf("failed to parse note response: %w", err)
+		}
+		for _, c := range resp.Data.Contents {
+			articles = append(articles, Article{
+				Title:       c.Name,
+				URL:         c.NoteURL,
+				PublishedAt: c.PublishAt,
+				Platform:    n.Platform(),
+			})
+		}
+
+		if resp.Data.IsLastPage || len(resp.Data.Contents) == 0 {
+			break
+		}
+	}
+	return articles, nil
+}
@@ -0,0 +1,100 @@
+// Package platform fetches the articles published on external platforms
+// (Zenn, note, SpeakerDeck, ...) for the article list in articles.json.
+package platform
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ArticlesData is the layout of articles.json
+type ArticlesData struct {
+	Articles []Article `json:"articles"`
+}
+
+// Article is an entry of articles.json
+type Article struct {
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	PublishedAt string `json:"published_at"`
+	UpdatedAt   string `json:"updated_at,omitempty"`
+	Platform    string `json:"platform"`
+}
+
+// Source fetches the articles of one user on one platform
+type Source interface {
+	// Platform returns the platform name stored in Article.Platform
+	Platform() string
+	// Fetch returns all articles of the user
+	Fetch(ctx context.Context) ([]Article, error)
+}
+
+// Result is the outcome of fetching one source
+type Result struct {
+	Platform string
+	Articles []Article
+	Err      error
+}
+
+// FetchAll fetches all sources concurrently.
+// Results are returned in the order of sources; a failing source does not affect the others.
+func FetchAll(ctx context.Context, sources []Source) []Result {
+	results := make([]Result, len(sources))
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Go(func() {
+			articles, err := source.Fetch(ctx)
+			results[i] = Result{Platform: source.Platform(), Articles: articles, Err: err}
+		})
+	}
+	wg.Wait()
+	return results
+}
+
+// Client sends HTTP requests. *http.Client implements it.
+type Client interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// TransportClient is a Client sending requests with its transport alone, leaving
+// redirects to the transport. Unlike http.Client it does not link the default
+// transport, which keeps the worker binary within the size limit.
+type TransportClient struct {
+	Transport http.RoundTripper
+}
+
+// Do implements Client
+func (c TransportClient) Do(req *http.Request) (*http.Response, error) {
+	return c.Transport.RoundTrip(req)
+}
+
+// userAgent is sent with every platform API request
+const userAgent = "Mozilla/5.0 (compatible; ujiprog-bot/1.0; +https://ujiprog.com)"
+
+// get fetches url with client and returns the response body
+func get(ctx context.Context, client Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return do(client, req)
+}
+
+// do sends req with client and returns the response body
+func do(client Client, req *http.Request) ([]byte, error) {
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: status %d", req.Method, req.URL, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,384 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// fixtureServer serves testdata files and records the requests it receives
+type fixtureServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+// Requests returns the requests received so far
+func (s *fixtureServer) Requests() []*http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// newFixtureServer serves testdata files by request URI (path and query).
+// "{{server}}" in a fixture is replaced with the server URL; other URIs are 404.
+func newFixtureServer(t *testing.T, fixtures map[string]string) *fixtureServer {
+	t.Helper()
+	srv := &fixtureServer{}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		srv.mu.Lock()
+		srv.requests = append(srv.requests, r)
+		srv.mu.Unlock()
+
+		name, ok := fixtures[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("User-Agent"); got != userAgent {
+			t.Errorf("User-Agent %q", got)
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Error(err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(strings.ReplaceAll(string(data), "{{server}}", srv.URL)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestZennFetch(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/api/articles?username=uji&order=latest&page=1": "zenn_page1.json",
+		"/api/articles?username=uji&order=latest&page=2": "zenn_page2.json",
+	})
+	z := NewZenn(srv.Client(), "uji")
+	z.baseURL = srv.URL
+
+	got, err := z.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Article{
+		{Title: "Go で Cloudflare Workers を書く", URL: srv.URL + "/uji/articles/go-workers", PublishedAt: "2026-01-10T09:00:00.000+09:00", UpdatedAt: "2026-01-12T10:30:00.000+09:00", Platform: "zenn"},
+		{Title: "R2 の使い方", URL: srv.URL + "/uji/articles/r2", PublishedAt: "2025-12-01T12:00:00.000+09:00", Platform: "zenn"},
+		{Title: "はじめての Zenn", URL: srv.URL + "/uji/articles/first", PublishedAt: "2020-12-06T00:01:09.118+09:00", Platform: "zenn"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestNoteFetch(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/api/v2/creators/uji/contents?kind=note&page=1": "note_page1.json",
+		"/api/v2/creators/uji/contents?kind=note&page=2": "note_page2.json",
+	})
+	n := NewNote(srv.Client(), "uji")
+	n.baseURL = srv.URL
+
+	got, err := n.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Article{
+		{Title: "一年のふりかえり", URL: "https://note.com/uji/n/n1111", PublishedAt: "2025-12-31T20:00:00+09:00", Platform: "note"},
+		{Title: "読書メモ", URL: "https://note.com/uji/n/n2222", PublishedAt: "2025-06-01T08:00:00+09:00", Platform: "note"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestSpeakerDeckFetch(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/uji.atom": "speakerdeck.atom",
+	})
+	s := NewSpeakerDeck(srv.Client(), "uji")
+	s.baseURL = srv.URL
+
+	got, err := s.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Article{
+		{Title: "Go と WebAssembly", URL: "https://speakerdeck.com/uji/go-and-wasm", PublishedAt: "2025-11-20T19:00:00-05:00", Platform: "speakerdeck"},
+		{Title: "LT", URL: "https://speakerdeck.com/uji/lt", PublishedAt: "2025-03-01T10:00:00+09:00", Platform: "speakerdeck"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestFetchAtomFollowsNextLinks(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/feed":        "hatena_page1.atom",
+		"/feed?page=2": "hatena_page2.atom",
+	})
+
+	got, err := fetchAtom(context.Background(), srv.Client(), srv.URL+"/feed", "hatena", maxHatenaPages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].URL != "https://uji.hatenablog.com/entry/new" || got[1].URL != "https://uji.hatenablog.com/entry/old" {
+		t.Errorf("got %+v", got)
+	}
+
+	// The page limit stops the pagination
+	got, err = fetchAtom(context.Background(), srv.Client(), srv.URL+"/feed", "hatena", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Errorf("got %d articles with one page, want 1", len(got))
+	}
+}
+
+func TestQiitaFetch(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/api/v2/users/uji/items?page=1&per_page=100": "qiita_page1.json",
+	})
+	q := NewQiita(srv.Client(), "uji")
+	q.baseURL = srv.URL
+
+	// A short first page ends the pagination
+	got, err := q.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Article{
+		{Title: "Go の range-over-func", URL: "https://qiita.com/uji/items/aaaa", PublishedAt: "2025-09-01T10:00:00+09:00", UpdatedAt: "2025-09-03T12:00:00+09:00", Platform: "qiita"},
+		{Title: "Vim の設定", URL: "https://qiita.com/uji/items/bbbb", PublishedAt: "2023-04-05T09:00:00+09:00", Platform: "qiita"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+	if n := len(srv.Requests()); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+}
+
+func TestDevToFetch(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/api/articles?username=uji&page=1&per_page=100": "devto_page1.json",
+	})
+	d := NewDevTo(srv.Client(), "uji")
+	d.baseURL = srv.URL
+
+	got, err := d.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Article{
+		{Title: "Writing Cloudflare Workers in Go", URL: "https://dev.to/uji/writing-cloudflare-workers-in-go-1abc", PublishedAt: "2025-10-01T01:00:00Z", UpdatedAt: "2025-10-02T03:00:00Z", Platform: "devto"},
+		{Title: "Hello dev.to", URL: "https://dev.to/uji/hello-devto-2def", PublishedAt: "2024-02-01T00:00:00Z", Platform: "devto"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+	if n := len(srv.Requests()); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+}
+
+// newFullPageServer returns a server answering every request with a full page
+// of perPage items built by item, and a counter of the requests
+func newFullPageServer(t *testing.T, perPage int, item func(page, i int) any) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := int(requests.Add(1))
+		items := make([]any, perPage)
+		for i := range items {
+			items[i] = item(page, i)
+		}
+		json.NewEncoder(w).Encode(items)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestPageLimits(t *testing.T) {
+	t.Run("qiita", func(t *testing.T) {
+		srv, requests := newFullPageServer(t, qiitaPerPage, func(page, i int) any {
+			return qiitaItem{Title: "t", URL: fmt.Sprintf("https://qiita.com/uji/items/%d-%d", page, i)}
+		})
+		q := NewQiita(srv.Client(), "uji")
+		q.baseURL = srv.URL
+		got, err := q.Fetch(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if requests.Load() != maxQiitaPages || len(got) != maxQiitaPages*qiitaPerPage {
+			t.Errorf("got %d articles in %d requests", len(got), requests.Load())
+		}
+	})
+	t.Run("devto", func(t *testing.T) {
+		srv, requests := newFullPageServer(t, devToPerPage, func(page, i int) any {
+			return devToArticle{Title: "t", URL: fmt.Sprintf("https://dev.to/uji/%d-%d", page, i)}
+		})
+		d := NewDevTo(srv.Client(), "uji")
+		d.baseURL = srv.URL
+		got, err := d.Fetch(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if requests.Load() != maxDevToPages || len(got) != maxDevToPages*devToPerPage {
+			t.Errorf("got %d articles in %d requests", len(got), requests.Load())
+		}
+	})
+}
+
+func TestConnpassFetch(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/api/v2/users/uji/presenter_events/?start=1&count=100": "connpass_page1.json",
+		"/api/v2/users/uji/presenter_events/?start=3&count=100": "connpass_page2.json",
+	})
+	c := NewConnpass(srv.Client(), "uji", "secret")
+	c.baseURL = srv.URL
+
+	got, err := c.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Talks are dated by the start of the event
+	want := []Article{
+		{Title: "Go Conference mini 2026", URL: "https://gocon.connpass.com/event/100/", PublishedAt: "2026-02-21T10:00:00+09:00", Platform: "connpass"},
+		{Title: "Vim 勉強会", URL: "https://vim.connpass.com/event/200/", PublishedAt: "2025-11-10T19:00:00+09:00", Platform: "connpass"},
+		{Title: "はじめての LT 会", URL: "https://lt.connpass.com/event/300/", PublishedAt: "2023-06-01T19:30:00+09:00", Platform: "connpass"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	requests := srv.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+	for _, r := range requests {
+		if key := r.Header.Get("X-API-Key"); key != "secret" {
+			t.Errorf("X-API-Key %q", key)
+		}
+	}
+}
+
+func TestConnpassRequiresAPIKey(t *testing.T) {
+	srv := newFixtureServer(t, nil)
+	c := NewConnpass(srv.Client(), "uji", "")
+	c.baseURL = srv.URL
+
+	if _, err := c.Fetch(context.Background()); err == nil {
+		t.Error("expected an error without an API key")
+	}
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("got %d requests without an API key, want 0", n)
+	}
+}
+
+func TestTransportClient(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/uji.atom": "speakerdeck.atom",
+	})
+	s := NewSpeakerDeck(TransportClient{Transport: srv.Client().Transport}, "uji")
+	s.baseURL = srv.URL
+
+	got, err := s.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Errorf("got %d articles, want 2", len(got))
+	}
+}
+
+func TestFetchError(t *testing.T) {
+	srv := newFixtureServer(t, nil)
+	z := NewZenn(srv.Client(), "uji")
+	z.baseURL = srv.URL
+	if _, err := z.Fetch(context.Background()); err == nil {
+		t.Error("expected an error for a 404 response")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	data := ArticlesData{Articles: []Article{
+		{Title: "Blog", URL: "/articles/hello", PublishedAt: "2026-01-22T00:00:00+09:00", Platform: "blog"},
+		{Title: "Old Zenn", URL: "https://zenn.dev/uji/articles/old", PublishedAt: "2024-01-01T00:00:00+09:00", Platform: "zenn"},
+		{Title: "Note", URL: "https://note.com/uji/n/n1111", PublishedAt: "2025-12-31T20:00:00+09:00", Platform: "note"},
+	}}
+	articles := []Article{
+		// 2026-01-21T16:00:00Z, one hour after the blog article although it sorts before it as a string
+		{Title: "Zenn", URL: "https://zenn.dev/uji/articles/new", PublishedAt: "2026-01-21T10:00:00-06:00", Platform: "zenn"},
+		// Published at the same instant as the note article: ordered by URL
+		{Title: "Zenn B", URL: "https://zenn.dev/uji/articles/b", PublishedAt: "2025-12-31T11:00:00Z", Platform: "zenn"},
+	}
+
+	got := Merge(data, []string{"zenn"}, articles)
+	var urls []string
+	for _, a := range got.Articles {
+		urls = append(urls, a.URL)
+	}
+	want := []string{
+		"https://zenn.dev/uji/articles/new",
+		"/articles/hello",
+		"https://note.com/uji/n/n1111",
+		"https://zenn.dev/uji/articles/b",
+	}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("got %v\nwant %v", urls, want)
+	}
+
+	// The result does not depend on the input order
+	reversed := []Article{articles[1], articles[0]}
+	if again := Merge(data, []string{"zenn"}, reversed); !reflect.DeepEqual(again, got) {
+		t.Errorf("order changed with reversed input: %+v", again)
+	}
+}
+
+func TestRefreshKeepsFailedPlatforms(t *testing.T) {
+	srv := newFixtureServer(t, map[string]string{
+		"/uji.atom": "speakerdeck.atom",
+	})
+	speakerDeck := NewSpeakerDeck(srv.Client(), "uji")
+	speakerDeck.baseURL = srv.URL
+	zenn := NewZenn(srv.Client(), "uji") // 404
+	zenn.baseURL = srv.URL
+
+	data := ArticlesData{Articles: []Article{
+		{Title: "Zenn", URL: "https://zenn.dev/uji/articles/x", PublishedAt: "2024-01-01T00:00:00Z", Platform: "zenn"},
+		{Title: "Removed slides", URL: "https://speakerdeck.com/uji/removed", PublishedAt: "2024-01-01T00:00:00Z", Platform: "speakerdeck"},
+	}}
+	got, err := Refresh(context.Background(), data, []Source{zenn, speakerDeck})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var urls []string
+	for _, a := range got.Articles {
+		urls = append(urls, a.URL)
+	}
+	want := []string{
+		"https://speakerdeck.com/uji/go-and-wasm",
+		"https://speakerdeck.com/uji/lt",
+		"https://zenn.dev/uji/articles/x",
+	}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("got %v\nwant %v", urls, want)
+	}
+
+	if _, err := Refresh(context.Background(), data, []Source{zenn}); err == nil {
+		t.Error("expected an error when every platform fails")
+	}
+}
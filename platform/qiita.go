@@ -0,0 +1,74 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// Qiita fetches articles from the Qiita API
+type Qiita struct {
+	client  Client
+	baseURL string
+	userID  string
+}
+
+// NewQiita creates a Qiita source for the user ID
+func NewQiita(client Client, userID string) *Qiita {
+	return &Qiita{client: client, baseURL: "https://qiita.com", userID: userID}
+}
+
+// qiitaItem is an element of GET /api/v2/users/{user_id}/items
+type qiitaItem struct {
+	Title     string `json:"title"`
+	URL       string `json:"url"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// qiitaPerPage is the maximum page size of the Qiita API
+const qiitaPerPage = 100
+
+// maxQiitaPages is the highest page number the Qiita API accepts
+const maxQiitaPages = 100
+
+// Platform implements Source
+func (q *Qiita) Platform() string {
+	return "qiita"
+}
+
+// Fetch implements Source, following the pagination of the API
+func (q *Qiita) Fetch(ctx context.Context) ([]Article, error) {
+	var articles []Article
+	for page := 1; page <= maxQiitaPages; page++ {
+		apiURL := fmt.Sprintf("%s/api/v2/users/%s/items?page=%d&per_page=%d",
+			q.baseURL, url.PathEscape(q.userID), page, qiitaPerPage)
+		body, err := get(ctx, q.client, apiURL)
+		if err != nil {
+			return nil, err
+		}
+
+		var items []qiitaItem
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, fmt.Errorf("failed to parse Qiita response: %w", err)
+		}
+		for _, item := range items {
+			article := Article{
+				Title:       item.Title,
+				URL:         item.URL,
+				PublishedAt: item.CreatedAt,
+				Platform:    q.Platform(),
+			}
+			if item.UpdatedAt != item.CreatedAt {
+				article.UpdatedAt = item.UpdatedAt
+			}
+			articles = append(articles, article)
+		}
+
+		if len(items) < qiitaPerPage {
+			break
+		}
+	}
+	return articles, nil
+}
@@ -0,0 +1,28 @@
+package platform
+
+import (
+	"context"
+	"net/url"
+)
+
+// SpeakerDeck fetches slides from the SpeakerDeck Atom feed
+type SpeakerDeck struct {
+	client   Client
+	baseURL  string
+	username string
+}
+
+// NewSpeakerDeck creates a SpeakerDeck source for username
+func NewSpeakerDeck(client Client, username string) *SpeakerDeck {
+	return &SpeakerDeck{client: client, baseURL: "https://speakerdeck.com", username: username}
+}
+
+// Platform implements Source
+func (s *SpeakerDeck) Platform() string {
+	return "speakerdeck"
+}
+
+// Fetch implements Source
+func (s *SpeakerDeck) Fetch(ctx context.Context) ([]Article, error) {
+	return fetchAtom(ctx, s.client, s.baseURL+"/"+url.PathEscape(s.username)+".atom", s.Platform(), 1)
+}
@@ -0,0 +1,71 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// Zenn fetches articles from the Zenn API
+type Zenn struct {
+	client   Client
+	baseURL  string
+	username string
+}
+
+// NewZenn creates a Zenn source for username
+func NewZenn(client Client, username string) *Zenn {
+	return &Zenn{client: client, baseURL: "https://zenn.dev", username: username}
+}
+
+// zennResponse is a page of GET /api/articles
+type zennResponse struct {
+	Articles []struct {
+		Title         string `json:"title"`
+		Slug          string `json:"slug"`
+		PublishedAt   string `json:"published_at"`
+		BodyUpdatedAt string `json:"body_updated_at"`
+	} `json:"articles"`
+	NextPage *int `json:"next_page"`
+}
+
+// Platform implements Source
+func (z *Zenn) Platform() string {
+	return "zenn"
+}
+
+// Fetch implements Source, following the pagination of the API
+func (z *Zenn) Fetch(ctx context.Context) ([]Article, error) {
+	var articles []Article
+	for page := 1; ; {
+		apiURL := fmt.Sprintf("%s/api/articles?username=%s&order=latest&page=%d",
+			z.baseURL, url.QueryEscape(z.username), page)
+		body, err := get(ctx, z.client, apiURL)
+		if err != nil {
+			return nil, err
+		}
+
+		var resp zennResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, fmt.Errorf("failed to parse Zenn response: %w", err)
+		}
+		for _, a := range resp.Articles {
+			article := Article{
+				Title:       a.Title,
+				URL:         z.baseURL + "/" + z.username + "/articles/" + a.Slug,
+				PublishedAt: a.PublishedAt,
+				Platform:    z.Platform(),
+			}
+			if a.BodyUpdatedAt != "" && a.BodyUpdatedAt != a.PublishedAt {
+				article.UpdatedAt = a.BodyUpdatedAt
+			}
+			articles = append(articles, article)
+		}
+
+		if resp.NextPage == nil || *resp.NextPage <= page {
+			return articles, nil
+		}
+		page = *resp.NextPage
+	}
+}
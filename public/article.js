@@ -70,3 +70,53 @@ document.addEventListener('DOMContentLoaded', function() {
     heading.appendChild(textLink);
   });
 });
+
+// Show footnotes as popovers next to their references
+document.addEventListener('DOMContentLoaded', function() {
+  var popover = null;
+
+  function closePopover() {
+    if (popover) {
+      popover.remove();
+      popover = null;
+    }
+  }
+
+  document.querySelectorAll('a[data-footnote]').forEach(function(ref) {
+    ref.addEventListener('click', function(e) {
+      var id = ref.getAttribute('data-footnote');
+      var note = document.getElementById(id);
+      if (!note) return;
+      e.preventDefault();
+      e.stopPropagation();
+
+      var reopened = popover && popover.getAttribute('data-footnote') === id;
+      closePopover();
+      if (reopened) return;
+
+      // Copy the footnote without its back-links
+      popover = document.createElement('div');
+      popover.className = 'footnote-popover';
+      popover.setAttribute('role', 'note');
+      popover.setAttribute('data-footnote', id);
+      popover.innerHTML = note.innerHTML;
+      popover.querySelectorAll('.footnote-backref').forEach(function(backref) {
+        backref.remove();
+      });
+      document.body.appendChild(popover);
+
+      // Place it below the reference, inside the viewport
+      var rect = ref.getBoundingClientRect();
+      var maxLeft = document.documentElement.clientWidth - popover.offsetWidth - 8;
+      popover.style.top = (rect.bottom + window.scrollY + 8) + 'px';
+      popover.style.left = (Math.max(8, Math.min(rect.left - 16, maxLeft)) + window.scrollX) + 'px';
+    });
+  });
+
+  document.addEventListener('click', function(e) {
+    if (popover && !popover.contains(e.target)) closePopover();
+  });
+  document.addEventListener('keydown', function(e) {
+    if (e.key === 'Escape') closePopover();
+  });
+});
@@ -0,0 +1,177 @@
+package site
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/uji/ujiprog.com/storage"
+)
+
+// publicPrefix is the key prefix of the files in public/, served at the top level
+// (/style.css is stored as public/style.css). Other keys such as og-meta.json are internal.
+const publicPrefix = "public/"
+
+// Cache-Control values for /articles/. Pages and OG images are rewritten in place
+// by cmd/generate under the same URL, so browsers revalidate pages on every visit
+// (cheap with ETags) and keep images for a day.
+const (
+	articleCacheControl = "public, max-age=0, must-revalidate"
+	ogImageCacheControl = "public, max-age=86400"
+)
+
+// assetPolicy describes how a static asset is served
+type assetPolicy struct {
+	contentType  string
+	cacheControl string
+}
+
+// assetPolicies maps file extensions to their content type and cache policy
+var assetPolicies = map[string]assetPolicy{
+	".html": {"text/html; charset=utf-8", "public, max-age=300"},
+	".css":  {"text/css; charset=utf-8", "public, max-age=604800"},
+	".js":   {"application/javascript; charset=utf-8", "public, max-age=604800"},
+	".json": {"application/json; charset=utf-8", "public, max-age=3600"},
+	".xml":  {"application/xml; charset=utf-8", "public, max-age=3600"},
+	".txt":  {"text/plain; charset=utf-8", "public, max-age=86400"},
+	".png":  {"image/png", "public, max-age=604800"},
+	".jpg":  {"image/jpeg", "public, max-age=604800"},
+	".webp": {"image/webp", "public, max-age=604800"},
+	".svg":  {"image/svg+xml", "public, max-age=604800"},
+	".ico":  {"image/x-icon", "public, max-age=604800"},
+}
+
+// lookupAssetPolicy returns the policy for the key's extension
+func lookupAssetPolicy(key string) (assetPolicy, bool) {
+	policy, ok := assetPolicies[strings.ToLower(path.Ext(key))]
+	return policy, ok
+}
+
+// ObjectMetadata returns the Content-Type and Cache-Control stored with key when
+// uploading it to the bucket, matching how the object is served
+func ObjectMetadata(key string) (contentType, cacheControl string) {
+	if policy, ok := lookupAssetPolicy(key); ok {
+		contentType, cacheControl = policy.contentType, policy.cacheControl
+	}
+	if strings.HasPrefix(key, "articles/") {
+		// Match articlesHandler
+		cacheControl = articleCacheControl
+		if strings.HasSuffix(key, ".png") {
+			cacheControl = ogImageCacheControl
+		}
+	}
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(key))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return contentType, cacheControl
+}
+
+// Content-Security-Policy values for HTML pages
+const (
+	indexCSP   = "default-src 'self'; style-src 'self' https://fonts.googleapis.com; font-src 'self' https://fonts.gstatic.com; img-src 'self' blob: data:; script-src 'self'; object-src 'none'; base-uri 'self'; frame-src https://platform.twitter.com https://syndication.twitter.com; frame-ancestors 'none';"
+	articleCSP = "default-src 'self'; style-src 'self' https://fonts.googleapis.com; font-src https://fonts.gstatic.com; img-src 'self' https: data: blob:; script-src 'self' https://platform.twitter.com; frame-src https://platform.twitter.com https://syndication.twitter.com;"
+)
+
+// setSecurityHeaders sets the security headers shared by all HTML pages
+func setSecurityHeaders(h http.Header, csp string) {
+	h.Set("Content-Security-Policy", csp)
+	h.Set("Cross-Origin-Opener-Policy", "same-origin")
+	h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("X-Frame-Options", "DENY")
+	h.Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains; preload")
+}
+
+// serveAsset writes the object stored at key to the response.
+// Content-Type and Cache-Control come from assetPolicies unless the caller has
+// already set them, and ETag / Last-Modified are taken from the object metadata
+// so that conditional requests can be answered with 304 Not Modified.
+func (s *Server) serveAsset(w http.ResponseWriter, req *http.Request, key string) {
+	obj, err := s.store.Get(key)
+	if err != nil || obj == nil {
+		http.NotFound(w, req)
+		return
+	}
+	s.serveObject(w, req, obj)
+}
+
+// serveObject writes an object already fetched from the store, closing its body
+func (s *Server) serveObject(w http.ResponseWriter, req *http.Request, obj *storage.Object) {
+	defer obj.Body.Close()
+
+	h := w.Header()
+	policy, _ := lookupAssetPolicy(obj.Key)
+	contentType := policy.contentType
+	if contentType == "" {
+		contentType = obj.ContentType
+	}
+	setDefaultHeader(h, "Content-Type", contentType)
+	setDefaultHeader(h, "Cache-Control", policy.cacheControl)
+
+	if obj.ETag != "" {
+		h.Set("ETag", obj.ETag)
+	}
+	if !obj.LastModified.IsZero() {
+		h.Set("Last-Modified", obj.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(req, obj.ETag, obj.LastModified) {
+		h.Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if req.Method == http.MethodHead {
+		return
+	}
+	io.Copy(w, obj.Body)
+}
+
+// setDefaultHeader sets the header only if it has not been set yet
+func setDefaultHeader(h http.Header, key, value string) {
+	if value == "" || h.Get(key) != "" {
+		return
+	}
+	h.Set(key, value)
+}
+
+// notModified evaluates If-None-Match and If-Modified-Since (RFC 9110 13.2.2).
+// If-Modified-Since is ignored when If-None-Match is present.
+func notModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		return etag != "" && etagMatches(inm, etag)
+	}
+
+	if ims := req.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		// HTTP dates have second precision
+		return !lastModified.Truncate(time.Second).After(t)
+	}
+
+	return false
+}
+
+// etagMatches reports whether an If-None-Match header matches etag using weak comparison
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,9 @@
-package main
+package site
 
 import (
 	"encoding/json"
 	"encoding/xml"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
@@ -17,6 +18,7 @@ type Article struct {
 	Title       string `json:"title"`
 	URL         string `json:"url"`
 	PublishedAt string `json:"published_at"`
+	UpdatedAt   string `json:"updated_at,omitempty"`
 	Platform    string `json:"platform"`
 }
 
@@ -56,26 +58,40 @@ type GUID struct {
 	IsPermaLink string `xml:"isPermaLink,attr"`
 }
 
-func feedHandler(w http.ResponseWriter, req *http.Request) {
-	obj, err := bucket.Get("articles.json")
+// articlesKey is the key of the article list in the store
+const articlesKey = publicPrefix + "articles.json"
+
+// loadArticles reads and decodes articles.json, returning nil if it does not exist
+func (s *Server) loadArticles() (*ArticlesData, error) {
+	obj, err := s.store.Get(articlesKey)
 	if err != nil {
-		http.Error(w, "get error: "+err.Error(), http.StatusInternalServerError)
-		return
+		return nil, fmt.Errorf("get error: %w", err)
 	}
 	if obj == nil {
-		http.Error(w, "articles.json not found", http.StatusNotFound)
-		return
+		return nil, nil
 	}
+	defer obj.Body.Close()
 
 	body, err := io.ReadAll(obj.Body)
 	if err != nil {
-		http.Error(w, "read error: "+err.Error(), http.StatusInternalServerError)
-		return
+		return nil, fmt.Errorf("read error: %w", err)
 	}
 
 	var data ArticlesData
 	if err := json.Unmarshal(body, &data); err != nil {
-		http.Error(w, "json error: "+err.Error(), http.StatusInternalServerError)
+		return nil, fmt.Errorf("json error: %w", err)
+	}
+	return &data, nil
+}
+
+func (s *Server) feedHandler(w http.ResponseWriter, req *http.Request) {
+	data, err := s.loadArticles()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if data == nil {
+		http.Error(w, "articles.json not found", http.StatusNotFound)
 		return
 	}
 
@@ -88,7 +104,7 @@ func feedHandler(w http.ResponseWriter, req *http.Request) {
 		// Convert relative path to absolute URL for RSS feed
 		link := article.URL
 		if strings.HasPrefix(link, "/") {
-			link = "https://ujiprog.com" + link
+			link = siteURL + link
 		}
 		items = append(items, Item{
 			Title: article.Title,
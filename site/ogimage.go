@@ -0,0 +1,313 @@
+package site
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uji/ujiprog.com/ogimage"
+	"github.com/uji/ujiprog.com/storage"
+)
+
+// OGMeta represents OG image metadata for an article
+type OGMeta = ogimage.Meta
+
+// OGMetaData maps article slug to OG metadata
+type OGMetaData map[string]OGMeta
+
+// Objects the OG image generator is built from.
+// Card templates live under ogTemplatePrefix as <name>.png with an optional <name>.json layout.
+const (
+	ogMetaKey        = "og-meta.json"
+	ogTemplatePrefix = "templates/"
+)
+
+// ogFontKeys is the font fallback chain for OG images, in order of preference.
+// Fonts missing from the store are skipped, so optional fonts such as the emoji
+// font only need to be uploaded when wanted.
+var ogFontKeys = []string{
+	"fonts/DMSans-Bold.ttf",
+	"fonts/NotoSansJP-Bold.ttf",
+	"fonts/NotoEmoji-Bold.ttf",
+}
+
+const (
+	// ogCachePrefix is the key prefix for rendered OG images
+	ogCachePrefix = "og-cache/"
+	// ogVersionMaxAge is how long the asset version is trusted before re-checking the ETags
+	ogVersionMaxAge = time.Minute
+)
+
+// ogAssets holds the parsed OG metadata, fonts and generators.
+// It lives as long as the Server (the worker isolate) and is rebuilt when the
+// version derived from the source objects' ETags changes, i.e. after a redeploy.
+type ogAssets struct {
+	version   string
+	checkedAt time.Time
+	meta      OGMetaData
+	fonts     [][]byte
+
+	mu         sync.Mutex // font.Face is not safe for concurrent use
+	generators map[string]*ogimage.Generator
+}
+
+// ogCache holds the ogAssets shared by all requests
+type ogCache struct {
+	mu     sync.Mutex
+	assets *ogAssets
+}
+
+// handleOGImage generates OG images dynamically.
+// Rendered images are stored under og-cache/ keyed by a hash of the card and
+// asset version, so each image is rendered once per deploy. Only the latest
+// render of each article is kept.
+func (s *Server) handleOGImage(w http.ResponseWriter, req *http.Request, path string) {
+	// Extract article slug from path (e.g., "my-article.png" -> "my-article")
+	slug := strings.TrimSuffix(path, ".png")
+
+	assets, err := s.loadOGAssets()
+	if err != nil {
+		http.Error(w, "Failed to load OG assets: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Find the metadata for this article
+	meta, ok := assets.meta[slug]
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	w.Header().Set("Cache-Control", ogImageCacheControl)
+
+	// Serve a previously rendered image if there is one
+	cacheKey := ogCacheKey(slug, meta, assets.version)
+	if obj, err := s.store.Get(cacheKey); err == nil && obj != nil {
+		s.serveObject(w, req, obj)
+		return
+	}
+
+	// Generate OG image
+	var buf bytes.Buffer
+	if err := s.generateOGImage(assets, meta, &buf); err != nil {
+		http.Error(w, "Failed to generate OG image: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.store.Put(cacheKey, bytes.NewReader(buf.Bytes()), &storage.PutOptions{
+		ContentType: "image/png",
+		// The cache key changes with the content, so the object itself is immutable
+		CacheControl: "public, max-age=31536000, immutable",
+	}); err != nil {
+		log.Printf("Failed to cache OG image %s: %v", cacheKey, err)
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(buf.Bytes())
+
+	if err := s.pruneOGCache(slug, cacheKey); err != nil {
+		log.Printf("Failed to prune OG images of %s: %v", slug, err)
+	}
+}
+
+// pruneOGCache deletes the renders of slug other than keep, left by earlier
+// versions of its metadata or the assets
+func (s *Server) pruneOGCache(slug, keep string) error {
+	objs, err := s.store.List(ogCachePrefix + slug + "-")
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		// The prefix also matches slugs that start with this one followed by "-"
+		if obj.Key == keep || ogCacheSlug(obj.Key) != slug {
+			continue
+		}
+		if err := s.store.Delete(obj.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ogCacheKey returns the store key for a rendered OG image
+func ogCacheKey(slug string, meta OGMeta, version string) string {
+	metaJSON, _ := json.Marshal(meta)
+	sum := sha256.Sum256([]byte(version + "\n" + string(metaJSON)))
+	return ogCachePrefix + slug + "-" + hex.EncodeToString(sum[:8]) + ".png"
+}
+
+// ogCacheSlug returns the slug of a key created by ogCacheKey
+func ogCacheSlug(key string) string {
+	name := strings.TrimSuffix(strings.TrimPrefix(key, ogCachePrefix), ".png")
+	// Drop "-" and the 16 hex digits of the hash
+	if len(name) < 17 || name[len(name)-17] != '-' {
+		return ""
+	}
+	return name[:len(name)-17]
+}
+
+// generateOGImage renders meta with the generator for its card template
+func (s *Server) generateOGImage(assets *ogAssets, meta OGMeta, w io.Writer) error {
+	assets.mu.Lock()
+	defer assets.mu.Unlock()
+
+	name, err := meta.TemplateName()
+	if err != nil {
+		return err
+	}
+
+	generator, ok := assets.generators[name]
+	if !ok {
+		generator, err = s.buildOGGenerator(name, assets.fonts)
+		if err != nil {
+			return err
+		}
+		assets.generators[name] = generator
+	}
+
+	return generator.Generate(meta.Card(), w)
+}
+
+// buildOGGenerator loads a card template and its layout.
+// A missing layout falls back to ogimage.DefaultLayout.
+func (s *Server) buildOGGenerator(name string, fonts [][]byte) (*ogimage.Generator, error) {
+	templateData, err := s.readObject(ogTemplatePrefix + name + ".png")
+	if err != nil {
+		return nil, err
+	}
+
+	var layout *ogimage.Layout
+	layoutObj, err := s.store.Get(ogTemplatePrefix + name + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("get layout: %w", err)
+	}
+	if layoutObj != nil {
+		defer layoutObj.Body.Close()
+		layoutData, err := io.ReadAll(layoutObj.Body)
+		if err != nil {
+			return nil, fmt.Errorf("read layout: %w", err)
+		}
+		if layout, err = ogimage.ParseLayout(layoutData); err != nil {
+			return nil, err
+		}
+	}
+
+	generator, err := ogimage.NewGenerator(templateData, fonts, layout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OG generator: %w", err)
+	}
+	return generator, nil
+}
+
+// loadOGAssets returns the cached ogAssets, reloading them if the source objects changed.
+// The version is re-checked at most once per ogVersionMaxAge.
+func (s *Server) loadOGAssets() (*ogAssets, error) {
+	s.og.mu.Lock()
+	defer s.og.mu.Unlock()
+
+	current := s.og.assets
+	if current != nil && time.Since(current.checkedAt) < ogVersionMaxAge {
+		return current, nil
+	}
+
+	version, err := s.ogVersion()
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && current.version == version {
+		current.checkedAt = time.Now()
+		return current, nil
+	}
+
+	assets, err := s.buildOGAssets(version)
+	if err != nil {
+		return nil, err
+	}
+	s.og.assets = assets
+	return assets, nil
+}
+
+// ogVersion derives a version key from the ETags of og-meta.json, the fonts
+// and every card template and layout
+func (s *Server) ogVersion() (string, error) {
+	var etags []string
+	for _, key := range append([]string{ogMetaKey}, ogFontKeys...) {
+		obj, err := s.store.Head(key)
+		if err != nil {
+			return "", fmt.Errorf("head %s: %w", key, err)
+		}
+		if obj == nil {
+			// Record missing objects so that uploading an optional font changes the version
+			etags = append(etags, "-")
+			continue
+		}
+		etags = append(etags, obj.ETag)
+	}
+
+	templates, err := s.store.List(ogTemplatePrefix)
+	if err != nil {
+		return "", fmt.Errorf("list %s: %w", ogTemplatePrefix, err)
+	}
+	for _, obj := range templates {
+		etags = append(etags, obj.Key+"="+obj.ETag)
+	}
+
+	return strings.Join(etags, ","), nil
+}
+
+// buildOGAssets downloads and parses the OG metadata and fonts.
+// Generators are created lazily per card template.
+func (s *Server) buildOGAssets(version string) (*ogAssets, error) {
+	ogMetaData, err := s.readObject(ogMetaKey)
+	if err != nil {
+		return nil, err
+	}
+	var ogMeta OGMetaData
+	if err := json.Unmarshal(ogMetaData, &ogMeta); err != nil {
+		return nil, fmt.Errorf("failed to parse OG metadata: %w", err)
+	}
+
+	var fonts [][]byte
+	for _, key := range ogFontKeys {
+		data, err := s.readObject(key)
+		if err != nil {
+			log.Printf("Skipping OG font: %v", err)
+			continue
+		}
+		fonts = append(fonts, data)
+	}
+
+	return &ogAssets{
+		version:    version,
+		checkedAt:  time.Now(),
+		meta:       ogMeta,
+		fonts:      fonts,
+		generators: make(map[string]*ogimage.Generator),
+	}, nil
+}
+
+// readObject reads the whole object stored at key
+func (s *Server) readObject(key string) ([]byte, error) {
+	obj, err := s.store.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", key, err)
+	}
+	if obj == nil {
+		return nil, fmt.Errorf("%s not found", key)
+	}
+	defer obj.Body.Close()
+
+	data, err := io.ReadAll(obj.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", key, err)
+	}
+	return data, nil
+}
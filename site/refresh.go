@@ -0,0 +1,53 @@
+package site
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/uji/ujiprog.com/platform"
+	"github.com/uji/ujiprog.com/storage"
+)
+
+// RefreshArticles fetches the external platforms and merges their articles into
+// articles.json in the store, keeping the blog articles written by cmd/generate.
+// It is run by the worker's Cron Trigger.
+func RefreshArticles(ctx context.Context, store storage.Store, sources []platform.Source) error {
+	var data platform.ArticlesData
+	obj, err := store.Get(articlesKey)
+	if err != nil {
+		return fmt.Errorf("get error: %w", err)
+	}
+	if obj != nil {
+		body, err := io.ReadAll(obj.Body)
+		obj.Body.Close()
+		if err != nil {
+			return fmt.Errorf("read error: %w", err)
+		}
+		if err := json.Unmarshal(body, &data); err != nil {
+			return fmt.Errorf("json error: %w", err)
+		}
+	}
+
+	data, err = platform.Refresh(ctx, data, sources)
+	if err != nil {
+		return err
+	}
+	body, err := data.Encode()
+	if err != nil {
+		return fmt.Errorf("json error: %w", err)
+	}
+
+	contentType, cacheControl := ObjectMetadata(articlesKey)
+	if err := store.Put(articlesKey, bytes.NewReader(body), &storage.PutOptions{
+		ContentType:  contentType,
+		CacheControl: cacheControl,
+	}); err != nil {
+		return fmt.Errorf("put error: %w", err)
+	}
+	log.Printf("Updated: %s (%d articles)", articlesKey, len(data.Articles))
+	return nil
+}
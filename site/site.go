@@ -0,0 +1,102 @@
+// Package site implements the HTTP handlers for ujiprog.com on top of a storage.Store
+package site
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/uji/ujiprog.com/storage"
+)
+
+// siteURL is the canonical origin used for absolute URLs
+const siteURL = "https://ujiprog.com"
+
+// Server serves the site from an object store
+type Server struct {
+	store storage.Store
+	mux   *http.ServeMux
+	og    ogCache
+}
+
+// New creates a Server that reads objects from store
+func New(store storage.Store) *Server {
+	s := &Server{
+		store: store,
+		mux:   http.NewServeMux(),
+	}
+	s.routes()
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mux.ServeHTTP(w, req)
+}
+
+// routes registers all handlers on the mux
+func (s *Server) routes() {
+	s.mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		w.Write([]byte(`User-agent: *
+Allow: /
+Allow: /articles/
+Allow: /feed.xml
+Allow: /avator.jpg
+
+Sitemap: ` + siteURL + `/sitemap.xml`))
+	})
+	s.mux.HandleFunc("/sitemap.xml", s.sitemapHandler)
+	s.mux.HandleFunc("/sitemaps/", s.sitemapPageHandler)
+	s.mux.HandleFunc("/feed.xml", s.feedHandler)
+	s.mux.HandleFunc("/articles/", s.articlesHandler)
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/" {
+			setSecurityHeaders(w.Header(), indexCSP)
+			s.serveAsset(w, req, "index.html")
+			return
+		}
+
+		// Serve the files in public/ at the top level (e.g. /style.css, /articles.json)
+		name := strings.TrimPrefix(req.URL.Path, "/")
+		if _, ok := lookupAssetPolicy(name); ok && !strings.Contains(name, "/") {
+			s.serveAsset(w, req, publicPrefix+name)
+			return
+		}
+
+		// Redirect unknown paths to root
+		http.Redirect(w, req, "/", http.StatusFound)
+	})
+}
+
+func (s *Server) articlesHandler(w http.ResponseWriter, req *http.Request) {
+	// Extract the path after /articles/
+	path := strings.TrimPrefix(req.URL.Path, "/articles/")
+	if path == "" {
+		http.NotFound(w, req)
+		return
+	}
+
+	// Serve OG images pre-rendered by cmd/generate, falling back to dynamic generation
+	if strings.HasSuffix(path, ".png") {
+		if obj, err := s.store.Get("articles/" + path); err == nil && obj != nil {
+			w.Header().Set("Cache-Control", ogImageCacheControl)
+			s.serveObject(w, req, obj)
+			return
+		}
+		s.handleOGImage(w, req, path)
+		return
+	}
+
+	// Default to HTML - add .html extension if not present
+	var r2Key string
+	if !strings.HasSuffix(path, ".html") {
+		r2Key = "articles/" + path + ".html"
+	} else {
+		r2Key = "articles/" + path
+	}
+
+	setSecurityHeaders(w.Header(), articleCSP)
+	w.Header().Set("Cache-Control", articleCacheControl)
+	s.serveAsset(w, req, r2Key)
+}
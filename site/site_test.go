@@ -0,0 +1,264 @@
+package site
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uji/ujiprog.com/ogimage"
+	"github.com/uji/ujiprog.com/platform"
+	"github.com/uji/ujiprog.com/storage"
+	"golang.org/x/image/font/gofont/gobold"
+)
+
+// newTestServer returns a Server over an in-memory store holding objects
+func newTestServer(t *testing.T, objects map[string][]byte) (*Server, *storage.Memory) {
+	t.Helper()
+	store := storage.NewMemory()
+	for key, data := range objects {
+		contentType, cacheControl := ObjectMetadata(key)
+		if err := store.Put(key, bytes.NewReader(data), &storage.PutOptions{
+			ContentType:  contentType,
+			CacheControl: cacheControl,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return New(store), store
+}
+
+// get performs a GET request against s
+func get(s *Server, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestArticlesHandler(t *testing.T) {
+	s, _ := newTestServer(t, map[string][]byte{
+		"articles/hello.html": []byte("<p>hello</p>"),
+		"articles/hello.png":  []byte("png"),
+	})
+
+	for _, target := range []string{"/articles/hello", "/articles/hello.html"} {
+		rec := get(s, target, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d", target, rec.Code)
+		}
+		if got := rec.Body.String(); got != "<p>hello</p>" {
+			t.Errorf("GET %s: body %q", target, got)
+		}
+		if got := rec.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+			t.Errorf("GET %s: Content-Type %q", target, got)
+		}
+		if got := rec.Header().Get("Content-Security-Policy"); got != articleCSP {
+			t.Errorf("GET %s: Content-Security-Policy %q", target, got)
+		}
+	}
+
+	// Conditional requests are answered with 304
+	etag := get(s, "/articles/hello", nil).Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("missing ETag")
+	}
+	rec := get(s, "/articles/hello", http.Header{"If-None-Match": {etag}})
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("If-None-Match: status %d, body %q", rec.Code, rec.Body.String())
+	}
+
+	// Pre-rendered OG images are served from the store
+	rec = get(s, "/articles/hello.png", nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != "png" {
+		t.Errorf("GET /articles/hello.png: status %d, body %q", rec.Code, rec.Body.String())
+	}
+
+	for _, target := range []string{"/articles/", "/articles/missing"} {
+		if rec := get(s, target, nil); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s: status %d, want 404", target, rec.Code)
+		}
+	}
+}
+
+func TestFeedHandler(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	if rec := get(s, "/feed.xml", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("without articles.json: status %d, want 404", rec.Code)
+	}
+
+	articles := `{"articles": [
+		{"title": "ブログ", "url": "/articles/hello", "published_at": "2026-01-22T00:00:00+09:00", "platform": "blog"},
+		{"title": "Zenn", "url": "https://zenn.dev/uji/articles/x", "published_at": "2026-01-01T00:00:00Z", "platform": "zenn"}
+	]}`
+	if err := store.Put(articlesKey, strings.NewReader(articles), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := get(s, "/feed.xml", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/rss+xml; charset=utf-8" {
+		t.Errorf("Content-Type %q", got)
+	}
+
+	var rss RSS
+	if err := xml.Unmarshal(rec.Body.Bytes(), &rss); err != nil {
+		t.Fatal(err)
+	}
+	items := rss.Channel.Items
+	if len(items) != 2 {
+		t.Fatalf("got %d items, want 2", len(items))
+	}
+	if items[0].Link != siteURL+"/articles/hello" {
+		t.Errorf("relative URL not made absolute: %q", items[0].Link)
+	}
+	if items[0].PubDate != "Thu, 22 Jan 2026 00:00:00 +0900" {
+		t.Errorf("pubDate %q", items[0].PubDate)
+	}
+	if items[1].Link != "https://zenn.dev/uji/articles/x" {
+		t.Errorf("external URL changed: %q", items[1].Link)
+	}
+}
+
+// testTemplatePNG returns a plain card template image
+func testTemplatePNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 120, 63))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	img.Set(0, 0, color.Black)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestHandleOGImage(t *testing.T) {
+	s, store := newTestServer(t, map[string][]byte{
+		ogMetaKey:     []byte(`{"hello": {"title": "こんにちは", "tags": ["go"]}}`),
+		ogFontKeys[0]: gobold.TTF,
+		ogTemplatePrefix + ogimage.DefaultTemplate + ".png": testTemplatePNG(t),
+	})
+
+	rec := get(s, "/articles/hello.png", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "image/png" {
+		t.Errorf("Content-Type %q", got)
+	}
+	if _, err := png.Decode(bytes.NewReader(rec.Body.Bytes())); err != nil {
+		t.Errorf("invalid PNG: %v", err)
+	}
+
+	// The rendered image is cached and served from the cache afterwards
+	cached, err := store.List(ogCachePrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cached) != 1 {
+		t.Fatalf("got %d cached images, want 1", len(cached))
+	}
+	again := get(s, "/articles/hello.png", nil)
+	if !bytes.Equal(again.Body.Bytes(), rec.Body.Bytes()) || again.Header().Get("ETag") != cached[0].ETag {
+		t.Error("second request was not served from the cache")
+	}
+
+	if rec := get(s, "/articles/missing.png", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown slug: status %d, want 404", rec.Code)
+	}
+}
+
+func TestHandleOGImageReplacesOldRenders(t *testing.T) {
+	s, store := newTestServer(t, map[string][]byte{
+		ogMetaKey:     []byte(`{"a": {"title": "A"}, "a-b": {"title": "A-B"}}`),
+		ogFontKeys[0]: gobold.TTF,
+		ogTemplatePrefix + ogimage.DefaultTemplate + ".png": testTemplatePNG(t),
+	})
+	get(s, "/articles/a.png", nil)
+	get(s, "/articles/a-b.png", nil)
+
+	// Changing the metadata renders a new image of "a" and deletes the old one
+	if err := store.Put(ogMetaKey, strings.NewReader(`{"a": {"title": "A2"}, "a-b": {"title": "A-B"}}`), nil); err != nil {
+		t.Fatal(err)
+	}
+	s.og.assets.checkedAt = time.Time{}
+	if rec := get(s, "/articles/a.png", nil); rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+
+	cached, err := store.List(ogCachePrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slugs := make(map[string]int)
+	for _, obj := range cached {
+		slugs[ogCacheSlug(obj.Key)]++
+	}
+	if len(cached) != 2 || slugs["a"] != 1 || slugs["a-b"] != 1 {
+		t.Errorf("cached renders: %v", slugs)
+	}
+}
+
+// fakeSource is a platform.Source returning fixed articles
+type fakeSource struct {
+	platform string
+	articles []platform.Article
+}
+
+func (f fakeSource) Platform() string { return f.platform }
+
+func (f fakeSource) Fetch(ctx context.Context) ([]platform.Article, error) {
+	return f.articles, nil
+}
+
+func TestRefreshArticles(t *testing.T) {
+	s, store := newTestServer(t, map[string][]byte{
+		articlesKey: []byte(`{"articles": [
+			{"title": "Blog", "url": "/articles/hello", "published_at": "2026-01-22T00:00:00+09:00", "platform": "blog"},
+			{"title": "Old", "url": "https://zenn.dev/uji/articles/old", "published_at": "2024-01-01T00:00:00+09:00", "platform": "zenn"}
+		]}`),
+	})
+	source := fakeSource{platform: "zenn", articles: []platform.Article{
+		{Title: "New", URL: "https://zenn.dev/uji/articles/new", PublishedAt: "2026-02-01T00:00:00+09:00", Platform: "zenn"},
+	}}
+	if err := RefreshArticles(context.Background(), store, []platform.Source{source}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := s.loadArticles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var urls []string
+	for _, a := range data.Articles {
+		urls = append(urls, a.URL)
+	}
+	want := []string{"https://zenn.dev/uji/articles/new", "/articles/hello"}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("got %v\nwant %v", urls, want)
+	}
+
+	head, err := store.Head(articlesKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wantType, wantCache := ObjectMetadata(articlesKey); head.ContentType != wantType || head.CacheControl != wantCache {
+		t.Errorf("metadata %q %q", head.ContentType, head.CacheControl)
+	}
+}
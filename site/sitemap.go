@@ -0,0 +1,166 @@
+package site
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSitemapURLs is the number of URLs allowed in a single sitemap by the sitemaps.org protocol.
+// When the site grows beyond it, /sitemap.xml becomes a sitemap index of /sitemaps/{n}.xml.
+const maxSitemapURLs = 50000
+
+type URLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []SitemapURL `xml:"url"`
+}
+
+type SitemapURL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+type SitemapIndex struct {
+	XMLName  xml.Name       `xml:"sitemapindex"`
+	XMLNS    string         `xml:"xmlns,attr"`
+	Sitemaps []SitemapEntry `xml:"sitemap"`
+}
+
+type SitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+const sitemapNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// sitemapURLs builds the sitemap entries for the top page, the feed and every blog article
+func sitemapURLs(data *ArticlesData) []SitemapURL {
+	var latest time.Time
+	var articleURLs []SitemapURL
+	for _, article := range data.Articles {
+		lastMod := articleLastMod(article)
+		if lastMod.After(latest) {
+			latest = lastMod
+		}
+		// Only pages hosted on this site belong in its sitemap
+		if article.Platform != "blog" || !strings.HasPrefix(article.URL, "/") {
+			continue
+		}
+		articleURLs = append(articleURLs, SitemapURL{
+			Loc:      siteURL + article.URL,
+			LastMod:  formatLastMod(lastMod),
+			Priority: "0.8",
+		})
+	}
+
+	urls := []SitemapURL{
+		{
+			Loc:        siteURL + "/",
+			LastMod:    formatLastMod(latest),
+			ChangeFreq: "daily",
+			Priority:   "1.0",
+		},
+		{
+			Loc:        siteURL + "/feed.xml",
+			LastMod:    formatLastMod(latest),
+			ChangeFreq: "weekly",
+			Priority:   "0.8",
+		},
+	}
+	return append(urls, articleURLs...)
+}
+
+// articleLastMod returns updated_at if set, otherwise published_at
+func articleLastMod(article Article) time.Time {
+	for _, value := range []string{article.UpdatedAt, article.PublishedAt} {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// formatLastMod formats t in W3C date format, or returns "" for the zero time
+func formatLastMod(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02")
+}
+
+// sitemapPageCount returns the number of sitemap files needed for n URLs
+func sitemapPageCount(n int) int {
+	return (n + maxSitemapURLs - 1) / maxSitemapURLs
+}
+
+// sitemapHandler serves /sitemap.xml as a urlset, or as a sitemap index once
+// the URLs no longer fit in a single file
+func (s *Server) sitemapHandler(w http.ResponseWriter, req *http.Request) {
+	data, err := s.loadArticles()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if data == nil {
+		data = &ArticlesData{}
+	}
+
+	urls := sitemapURLs(data)
+	if len(urls) <= maxSitemapURLs {
+		writeSitemapXML(w, URLSet{XMLNS: sitemapNS, URLs: urls})
+		return
+	}
+
+	index := SitemapIndex{XMLNS: sitemapNS}
+	for page := 1; page <= sitemapPageCount(len(urls)); page++ {
+		index.Sitemaps = append(index.Sitemaps, SitemapEntry{
+			Loc:     fmt.Sprintf("%s/sitemaps/%d.xml", siteURL, page),
+			LastMod: urls[0].LastMod,
+		})
+	}
+	writeSitemapXML(w, index)
+}
+
+// sitemapPageHandler serves /sitemaps/{n}.xml referenced from the sitemap index
+func (s *Server) sitemapPageHandler(w http.ResponseWriter, req *http.Request) {
+	name := strings.TrimPrefix(req.URL.Path, "/sitemaps/")
+	page, err := strconv.Atoi(strings.TrimSuffix(name, ".xml"))
+	if err != nil || !strings.HasSuffix(name, ".xml") || page < 1 {
+		http.NotFound(w, req)
+		return
+	}
+
+	data, err := s.loadArticles()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if data == nil {
+		data = &ArticlesData{}
+	}
+
+	urls := sitemapURLs(data)
+	start := (page - 1) * maxSitemapURLs
+	if start >= len(urls) {
+		http.NotFound(w, req)
+		return
+	}
+	end := min(start+maxSitemapURLs, len(urls))
+	writeSitemapXML(w, URLSet{XMLNS: sitemapNS, URLs: urls[start:end]})
+}
+
+// writeSitemapXML writes v as an XML document with sitemap headers
+func writeSitemapXML(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	enc.Encode(v)
+}
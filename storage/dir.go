@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Dir is a Store backed by a local directory laid out like the bucket
+// (e.g. the .generated/ output of cmd/generate)
+type Dir struct {
+	root string
+}
+
+// NewDir creates a Store rooted at the given directory
+func NewDir(root string) *Dir {
+	return &Dir{root: root}
+}
+
+// path converts a key to a file path, rejecting keys that escape the root
+func (d *Dir) path(key string) (string, error) {
+	local, err := filepath.Localize(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d.root, local), nil
+}
+
+// Get implements Store
+func (d *Dir) Get(key string) (*Object, error) {
+	obj, data, err := d.read(key)
+	if err != nil || obj == nil {
+		return nil, err
+	}
+	obj.Body = io.NopCloser(bytes.NewReader(data))
+	return obj, nil
+}
+
+// Head implements Store
+func (d *Dir) Head(key string) (*Object, error) {
+	obj, _, err := d.read(key)
+	return obj, err
+}
+
+// read loads the file for key and builds its metadata
+func (d *Dir) read(key string) (*Object, []byte, error) {
+	p, err := d.path(key)
+	if err != nil {
+		// Keys that cannot be mapped to a file never exist
+		return nil, nil, nil
+	}
+
+	info, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &Object{
+		Key:          key,
+		Size:         info.Size(),
+		ETag:         contentETag(data),
+		LastModified: info.ModTime(),
+	}, data, nil
+}
+
+// Put implements Store. Options are ignored because files carry no HTTP metadata.
+func (d *Dir) Put(key string, body io.Reader, opts *PutOptions) error {
+	p, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0644)
+}
+
+// Delete implements Store
+func (d *Dir) Delete(key string) error {
+	p, err := d.path(key)
+	if err != nil {
+		return nil
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List implements Store
+func (d *Dir) List(prefix string) ([]*Object, error) {
+	var result []*Object
+	err := filepath.WalkDir(d.root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(d.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		obj, err := d.Head(key)
+		if err != nil || obj == nil {
+			return err
+		}
+		result = append(result, obj)
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return result, err
+}
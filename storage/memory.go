@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory is an in-memory Store, safe for concurrent use
+type Memory struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+// memoryObject is an object held by Memory
+type memoryObject struct {
+	meta Object
+	data []byte
+}
+
+// NewMemory creates an empty in-memory Store
+func NewMemory() *Memory {
+	return &Memory{
+		objects: make(map[string]memoryObject),
+	}
+}
+
+// Get implements Store
+func (m *Memory) Get(key string) (*Object, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	o, ok := m.objects[key]
+	if !ok {
+		return nil, nil
+	}
+	obj := o.meta
+	obj.Body = io.NopCloser(bytes.NewReader(o.data))
+	return &obj, nil
+}
+
+// Head implements Store
+func (m *Memory) Head(key string) (*Object, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	o, ok := m.objects[key]
+	if !ok {
+		return nil, nil
+	}
+	obj := o.meta
+	return &obj, nil
+}
+
+// Put implements Store
+func (m *Memory) Put(key string, body io.Reader, opts *PutOptions) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	meta := Object{
+		Key:          key,
+		Size:         int64(len(data)),
+		ETag:         contentETag(data),
+		LastModified: time.Now(),
+	}
+	if opts != nil {
+		meta.ContentType = opts.ContentType
+		meta.CacheControl = opts.CacheControl
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = memoryObject{meta: meta, data: data}
+	return nil
+}
+
+// Delete implements Store
+func (m *Memory) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	return nil
+}
+
+// List implements Store
+func (m *Memory) List(prefix string) ([]*Object, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []*Object
+	for key, o := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			obj := o.meta
+			result = append(result, &obj)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
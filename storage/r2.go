@@ -0,0 +1,158 @@
+//go:build js && wasm
+
+package storage
+
+import (
+	"errors"
+	"io"
+	"syscall/js"
+	"time"
+
+	"github.com/syumai/workers/cloudflare"
+	"github.com/syumai/workers/cloudflare/r2"
+)
+
+// R2 is a Store backed by a Cloudflare R2 bucket binding
+type R2 struct {
+	bucket *r2.Bucket
+	// binding is the JavaScript bucket, for the methods r2.Bucket does not cover
+	binding js.Value
+}
+
+// NewR2 creates a Store for the R2 bucket bound to the given variable name
+func NewR2(binding string) (*R2, error) {
+	bucket, err := r2.NewBucket(binding)
+	if err != nil {
+		return nil, err
+	}
+	return &R2{bucket: bucket, binding: cloudflare.GetBinding(binding)}, nil
+}
+
+// Get implements Store
+func (s *R2) Get(key string) (*Object, error) {
+	obj, err := s.bucket.Get(key)
+	if err != nil || obj == nil {
+		return nil, err
+	}
+	return fromR2Object(obj), nil
+}
+
+// Head implements Store
+func (s *R2) Head(key string) (*Object, error) {
+	obj, err := s.bucket.Head(key)
+	if err != nil || obj == nil {
+		return nil, err
+	}
+	return fromR2Object(obj), nil
+}
+
+// Put implements Store
+func (s *R2) Put(key string, body io.Reader, opts *PutOptions) error {
+	var r2Opts *r2.PutOptions
+	if opts != nil {
+		r2Opts = &r2.PutOptions{
+			HTTPMetadata: r2.HTTPMetadata{
+				ContentType:  opts.ContentType,
+				CacheControl: opts.CacheControl,
+			},
+		}
+	}
+	_, err := s.bucket.Put(key, io.NopCloser(body), r2Opts)
+	return err
+}
+
+// Delete implements Store
+func (s *R2) Delete(key string) error {
+	return s.bucket.Delete(key)
+}
+
+// List implements Store.
+// The R2 binding exposed by syumai/workers does not accept list options, so the
+// bucket's list method is called directly to pass the prefix and follow the cursor.
+func (s *R2) List(prefix string) ([]*Object, error) {
+	var result []*Object
+	cursor := ""
+	for {
+		opts := map[string]any{"prefix": prefix}
+		if cursor != "" {
+			opts["cursor"] = cursor
+		}
+		page, err := awaitPromise(s.binding.Call("list", opts))
+		if err != nil {
+			return nil, err
+		}
+
+		objects := page.Get("objects")
+		for i := range objects.Length() {
+			result = append(result, fromJSObject(objects.Index(i)))
+		}
+		if !page.Get("truncated").Truthy() {
+			return result, nil
+		}
+		cursor = page.Get("cursor").String()
+	}
+}
+
+// fromJSObject converts the metadata of an R2Object returned by list to an Object
+func fromJSObject(v js.Value) *Object {
+	o := &Object{
+		Key:          v.Get("key").String(),
+		Size:         int64(v.Get("size").Int()),
+		ETag:         v.Get("httpEtag").String(),
+		LastModified: time.UnixMilli(int64(v.Get("uploaded").Call("getTime").Float())),
+	}
+	// httpMetadata is only included when requested with the include option
+	if meta := v.Get("httpMetadata"); meta.Truthy() {
+		if ct := meta.Get("contentType"); ct.Type() == js.TypeString {
+			o.ContentType = ct.String()
+		}
+		if cc := meta.Get("cacheControl"); cc.Type() == js.TypeString {
+			o.CacheControl = cc.String()
+		}
+	}
+	return o
+}
+
+// awaitPromise waits for a JavaScript promise to settle
+func awaitPromise(promise js.Value) (js.Value, error) {
+	resultCh := make(chan js.Value, 1)
+	errCh := make(chan error, 1)
+	then := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		resultCh <- args[0]
+		return nil
+	})
+	defer then.Release()
+	catch := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		errCh <- errors.New(args[0].Call("toString").String())
+		return nil
+	})
+	defer catch.Release()
+
+	promise.Call("then", then, catch)
+	select {
+	case result := <-resultCh:
+		return result, nil
+	case err := <-errCh:
+		return js.Value{}, err
+	}
+}
+
+// fromR2Object converts an r2.Object to an Object
+func fromR2Object(obj *r2.Object) *Object {
+	o := &Object{
+		Key:          obj.Key,
+		Size:         int64(obj.Size),
+		ETag:         obj.HTTPETag,
+		LastModified: obj.Uploaded,
+		ContentType:  obj.HTTPMetadata.ContentType,
+		CacheControl: obj.HTTPMetadata.CacheControl,
+	}
+	if obj.Body != nil {
+		if rc, ok := obj.Body.(io.ReadCloser); ok {
+			o.Body = rc
+		} else {
+			o.Body = io.NopCloser(obj.Body)
+		}
+	}
+	return o
+}
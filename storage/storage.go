@@ -0,0 +1,52 @@
+// Package storage abstracts the object store the site is served from, so the
+// same handlers run against R2 in the worker and against local files or memory
+// elsewhere.
+package storage
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"time"
+)
+
+// Object represents a stored object and its metadata
+type Object struct {
+	Key          string
+	Size         int64
+	ETag         string // quoted entity tag suitable for the HTTP ETag header
+	LastModified time.Time
+	ContentType  string
+	CacheControl string
+	// Body is the object content.
+	// This value is nil for the result of Head and List.
+	Body io.ReadCloser
+}
+
+// PutOptions holds the HTTP metadata stored with an object
+type PutOptions struct {
+	ContentType  string
+	CacheControl string
+}
+
+// Store is a key-value object store such as an R2 bucket.
+// Keys use forward slashes regardless of the implementation (e.g. "articles/foo.html").
+type Store interface {
+	// Get returns the object with its body, or nil if the key does not exist.
+	// The caller must close Body.
+	Get(key string) (*Object, error)
+	// Head returns the object metadata without a body, or nil if the key does not exist.
+	Head(key string) (*Object, error)
+	// Put stores body under key, replacing any existing object.
+	Put(key string, body io.Reader, opts *PutOptions) error
+	// Delete removes the object stored at key. Deleting a missing key is not an error.
+	Delete(key string) error
+	// List returns the metadata of all objects whose key starts with prefix.
+	List(prefix string) ([]*Object, error)
+}
+
+// contentETag returns a quoted ETag derived from the content, matching R2's MD5-based ETags
+func contentETag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}